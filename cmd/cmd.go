@@ -7,6 +7,7 @@ import (
 	"github.com/zachwalton/devoid/pkg/config"
 	"github.com/zachwalton/devoid/pkg/errors"
 	"github.com/zachwalton/devoid/pkg/llm"
+	"github.com/zachwalton/devoid/pkg/tui"
 
 	"github.com/urfave/cli/v3"
 )
@@ -15,17 +16,60 @@ var Cmd = &cli.Command{
 	Name:      "main",
 	ArgsUsage: "prompt: the prompt used to bootstrap the project",
 	Usage:     "Generate a codebase from scratch interactively",
+	Commands: []*cli.Command{
+		rollbackCmd,
+		logCmd,
+		graphCmd,
+		reportCmd,
+		templatesCmd,
+	},
 	Flags: []cli.Flag{
 		&cli.StringFlag{
-			Name:     "project-path",
-			Usage:    "Path to the directory where the project should be created. The directory should be empty, and will be created if it doesn't exist",
-			Required: true,
+			Name:  "config",
+			Usage: "Path to a YAML config file. Defaults to devoid/config.yaml in the user config directory, if it exists. Flags take precedence over the config file",
+		},
+		&cli.StringFlag{
+			Name:  "project-path",
+			Usage: "Path to the directory where the project should be created. The directory should be empty, and will be created if it doesn't exist, unless --existing is set. Required by every command except templates vars, templates list and templates dump with --user or --output",
+		},
+		&cli.BoolFlag{
+			Name:  "existing",
+			Usage: "When true, --project-path is an existing codebase that will be scanned and evolved in place instead of bootstrapped from scratch",
+			Value: false,
 		},
 		&cli.BoolFlag{
 			Name:  "skip-interactive-safety-checks",
 			Usage: "When true, commands will be run without prompting. Use cautiously",
 			Value: false,
 		},
+		&cli.BoolFlag{
+			Name:  "sandbox.no-network",
+			Usage: "When true, sandboxed commands run without network access",
+		},
+		&cli.BoolFlag{
+			Name:  "sandbox.allow-unsandboxed",
+			Usage: "When true, commands run with your full privileges if the sandbox isn't available. Use cautiously",
+		},
+		&cli.StringFlag{
+			Name:  "blueprints-dir",
+			Usage: "Directory of additional project blueprints (*.yaml) to offer alongside the built-in ones. Blueprints with the same name replace built-in ones",
+		},
+		&cli.BoolFlag{
+			Name:  "no-git",
+			Usage: "When true, the project isn't initialized as a git repository and accepted stages aren't committed",
+		},
+		&cli.BoolFlag{
+			Name:  "no-history",
+			Usage: "When true, change requests and answers aren't saved to or recalled from the input history",
+		},
+		&cli.BoolFlag{
+			Name:  "ui.no-color",
+			Usage: "When true, the terminal UI doesn't use colors. Setting NO_COLOR does the same",
+		},
+		&cli.BoolFlag{
+			Name:  "ui.plain",
+			Usage: "When true, screens are printed line by line and answers are read from stdin. This is the default when stdout isn't a terminal",
+		},
 		&cli.StringFlag{
 			Name:  "llm.model",
 			Usage: "Name of the model to use, e.g. deepseek-r1:8b for Ollama",
@@ -47,6 +91,9 @@ var Cmd = &cli.Command{
 		if err != nil {
 			return err
 		}
+		if err := tui.Configure(cfg.UI); err != nil {
+			return err
+		}
 
 		var reasoner llm.Reasoner
 
@@ -65,6 +112,7 @@ var Cmd = &cli.Command{
 			cfg.ProjectPath,
 			cfg,
 		)
+		tui.Close()
 		return nil
 	},
 }
@@ -74,14 +122,60 @@ func setUpCfg(cmd *cli.Command) (*config.Config, error) {
 	if prompt == "" {
 		return nil, errors.ErrNoPrompt
 	}
-	return &config.Config{
-		ProjectPath:                 cmd.String("project-path"),
-		SkipInteractiveSafetyChecks: cmd.Bool("skip-interactive-safety-checks"),
-		Prompt:                      prompt,
-		LLM: config.LLM{
-			Model:       cmd.String("llm.model"),
-			Type:        config.Reasoner(cmd.String("llm.type")),
-			Temperature: cmd.Float("llm.temperature"),
-		},
-	}, nil
+
+	path, required := cmd.String("config"), true
+	if path == "" {
+		path, required = config.DefaultPath(), false
+	}
+	cfg, err := config.Load(path, required)
+	if err != nil {
+		return nil, err
+	}
+
+	// Flags take precedence over the config file when they're set explicitly,
+	// and flag defaults fill in anything the config file leaves out.
+	cfg.Prompt = prompt
+	if cfg.ProjectPath, err = requireProjectPath(cmd); err != nil {
+		return nil, err
+	}
+	setBool(cmd, "existing", &cfg.Existing)
+	setBool(cmd, "skip-interactive-safety-checks", &cfg.SkipInteractiveSafetyChecks)
+	setString(cmd, "blueprints-dir", &cfg.BlueprintsDir)
+	setBool(cmd, "no-git", &cfg.NoGit)
+	setBool(cmd, "no-history", &cfg.NoHistory)
+	setBool(cmd, "sandbox.no-network", &cfg.Sandbox.NoNetwork)
+	setBool(cmd, "sandbox.allow-unsandboxed", &cfg.Sandbox.AllowUnsandboxed)
+	setBool(cmd, "ui.no-color", &cfg.UI.NoColor)
+	setBool(cmd, "ui.plain", &cfg.UI.Plain)
+	setString(cmd, "llm.model", &cfg.LLM.Model)
+	if cmd.IsSet("llm.type") || cfg.LLM.Type == "" {
+		cfg.LLM.Type = config.Reasoner(cmd.String("llm.type"))
+	}
+	if cmd.IsSet("llm.temperature") || cfg.LLM.Temperature == 0 {
+		cfg.LLM.Temperature = cmd.Float("llm.temperature")
+	}
+	return cfg, nil
+}
+
+// requireProjectPath returns --project-path. It isn't a required flag because some
+// templates subcommands don't use a project, so commands that do check it
+// here.
+func requireProjectPath(cmd *cli.Command) (string, error) {
+	path := cmd.String("project-path")
+	if path == "" {
+		return "", errors.ErrNoProjectPath
+	}
+	return path, nil
+}
+
+func setString(cmd *cli.Command, name string, v *string) {
+	if cmd.IsSet(name) || *v == "" {
+		*v = cmd.String(name)
+	}
+}
+
+func setBool(cmd *cli.Command, name string, v *bool) {
+	if cmd.IsSet(name) {
+		*v = cmd.Bool(name)
+	}
 }
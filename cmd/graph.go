@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/zachwalton/devoid/pkg/checkpoint"
+	"github.com/zachwalton/devoid/pkg/errors"
+	"github.com/zachwalton/devoid/pkg/graph"
+
+	"github.com/charmbracelet/log"
+	"github.com/urfave/cli/v3"
+)
+
+// graphCmd inherits --project-path from the main command.
+var graphCmd = &cli.Command{
+	Name:  "graph",
+	Usage: "Export the planned dependency graph from the project's latest checkpoint",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "Output format: " + strings.Join(graph.Formats, ", "),
+			Value: graph.FormatDOT,
+		},
+		&cli.StringFlag{
+			Name:  "output",
+			Usage: "File to write the graph to. Defaults to stdout",
+		},
+		&cli.StringFlag{
+			Name:  "stage",
+			Usage: "Use the checkpoint for this stage instead of the latest one",
+		},
+		&cli.BoolFlag{
+			Name:  "markdown",
+			Usage: "When true, Mermaid output is wrapped in a mermaid code block so it can be pasted into Markdown, e.g. a README",
+		},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		projectPath, err := requireProjectPath(cmd)
+		if err != nil {
+			return err
+		}
+		var c *checkpoint.Checkpoint
+		if stage := cmd.String("stage"); stage != "" {
+			c, err = checkpoint.Load(projectPath, stage)
+		} else {
+			c, err = checkpoint.Latest(projectPath)
+		}
+		if err != nil {
+			return err
+		}
+		if c.Payload == nil || len(c.Payload.AST) == 0 {
+			return fmt.Errorf("%w: the %s checkpoint doesn't have one yet, accept the ast stage first", errors.ErrNoGraph, c.Stage)
+		}
+
+		out, err := graph.New(c.Payload.Meta.Name, c.Payload.AST).Export(cmd.String("format"))
+		if err != nil {
+			return err
+		}
+		if cmd.Bool("markdown") && cmd.String("format") == graph.FormatMermaid {
+			out = "```mermaid\n" + out + "```\n"
+		}
+		if path := cmd.String("output"); path != "" {
+			if err := os.WriteFile(path, []byte(out), 0o644); err != nil {
+				return err
+			}
+			log.Info("wrote dependency graph", "stage", c.Stage, "format", cmd.String("format"), "path", path)
+			return nil
+		}
+		fmt.Print(out)
+		return nil
+	},
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/zachwalton/devoid/pkg/audit"
+
+	"github.com/urfave/cli/v3"
+)
+
+// logCmd inherits --project-path from the main command.
+var logCmd = &cli.Command{
+	Name:  "log",
+	Usage: "Browse the audit log of model requests, responses, decisions, commands and writes",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "sessions",
+			Usage: "List sessions instead of showing events",
+		},
+		&cli.StringFlag{
+			Name:  "session",
+			Usage: "ID, or unique ID prefix, of the session to show. Defaults to the most recent session",
+		},
+		&cli.StringFlag{
+			Name:  "stage",
+			Usage: "Only show events for this stage",
+		},
+		&cli.StringSliceFlag{
+			Name:  "type",
+			Usage: "Only show events of these types: session_start, session_end, request, response, validation_error, decision, command, write or error",
+		},
+		&cli.DurationFlag{
+			Name:  "since",
+			Usage: "Only show events from this long ago, e.g. 30m",
+		},
+		&cli.StringFlag{
+			Name:  "grep",
+			Usage: "Only show events containing this text, case-insensitively",
+		},
+		&cli.BoolFlag{
+			Name:  "full",
+			Usage: "Show every field of each event instead of a one-line summary",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print matching events as JSON lines",
+		},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		projectPath, err := requireProjectPath(cmd)
+		if err != nil {
+			return err
+		}
+		if cmd.Bool("sessions") {
+			sessions, err := audit.Sessions(projectPath)
+			if err != nil {
+				return err
+			}
+			for _, s := range sessions {
+				fmt.Printf("%s  %s  %d events\n", s.ID, s.Start.Local().Format(time.DateTime), s.Events)
+			}
+			return nil
+		}
+
+		session, err := audit.Find(projectPath, cmd.String("session"))
+		if err != nil {
+			return err
+		}
+		events, err := audit.Read(session.Path)
+		if err != nil {
+			return err
+		}
+		filter := audit.Filter{
+			Stage:    cmd.String("stage"),
+			Types:    cmd.StringSlice("type"),
+			Contains: cmd.String("grep"),
+		}
+		if since := cmd.Duration("since"); since > 0 {
+			filter.Since = time.Now().Add(-since)
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		if cmd.Bool("full") {
+			enc.SetIndent("", "  ")
+		}
+		for _, e := range events {
+			if !filter.Match(e) {
+				continue
+			}
+			if cmd.Bool("json") || cmd.Bool("full") {
+				if err := enc.Encode(e); err != nil {
+					return err
+				}
+				continue
+			}
+			where := e.Stage
+			if e.Iteration > 0 {
+				where = fmt.Sprintf("%s#%d", e.Stage, e.Iteration)
+			}
+			fmt.Printf("%s  %-10s  %-16s  %s\n", e.Time.Local().Format(time.TimeOnly), where, e.Type, e.Summary())
+		}
+		return nil
+	},
+}
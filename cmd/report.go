@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/zachwalton/devoid/pkg/report"
+
+	"github.com/charmbracelet/log"
+	"github.com/urfave/cli/v3"
+)
+
+// reportCmd inherits --project-path from the main command.
+var reportCmd = &cli.Command{
+	Name:  "report",
+	Usage: "Export a design report of how the project came to be, from its checkpoints and audit log",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "Output format: " + strings.Join(report.Formats, ", "),
+			Value: report.FormatMarkdown,
+		},
+		&cli.StringFlag{
+			Name:  "output",
+			Usage: "File to write the report to. Defaults to stdout",
+		},
+		&cli.StringFlag{
+			Name:  "session",
+			Usage: "ID, or unique ID prefix, of the only session to include. Defaults to every session",
+		},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		projectPath, err := requireProjectPath(cmd)
+		if err != nil {
+			return err
+		}
+		r, err := report.Build(projectPath, cmd.String("session"))
+		if err != nil {
+			return err
+		}
+		out, err := r.Export(cmd.String("format"))
+		if err != nil {
+			return err
+		}
+		if path := cmd.String("output"); path != "" {
+			if err := os.WriteFile(path, []byte(out), 0o644); err != nil {
+				return err
+			}
+			log.Info("wrote design report", "format", cmd.String("format"), "stages", len(r.Stages), "sessions", len(r.Sessions), "path", path)
+			return nil
+		}
+		fmt.Print(out)
+		return nil
+	},
+}
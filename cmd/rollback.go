@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zachwalton/devoid/pkg/errors"
+	"github.com/zachwalton/devoid/pkg/git"
+
+	"github.com/charmbracelet/log"
+	"github.com/urfave/cli/v3"
+)
+
+// rollbackCmd inherits --project-path from the main command.
+var rollbackCmd = &cli.Command{
+	Name:  "rollback",
+	Usage: "Reset the project to the commit made when a stage was accepted",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "to-stage",
+			Usage:    "Name of the stage to roll back to, e.g. initial or ast. The most recent commit for the stage is used",
+			Required: true,
+		},
+		&cli.BoolFlag{
+			Name:  "force",
+			Usage: "When true, uncommitted changes in the project are discarded",
+		},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		projectPath, err := requireProjectPath(cmd)
+		if err != nil {
+			return err
+		}
+		repo, err := git.OpenExisting(projectPath)
+		if err != nil {
+			return err
+		}
+		commit, err := repo.FindStage(cmd.String("to-stage"))
+		if err != nil {
+			return err
+		}
+		dirty, err := repo.Dirty()
+		if err != nil {
+			return err
+		}
+		if dirty && !cmd.Bool("force") {
+			return fmt.Errorf("%w: commit or discard them, or pass --force", errors.ErrDirtyWorktree)
+		}
+		if err := repo.Reset(commit); err != nil {
+			return err
+		}
+		log.Info("rolled back", "stage", cmd.String("to-stage"), "commit", commit[:min(len(commit), 12)])
+		return nil
+	},
+}
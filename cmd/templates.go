@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/zachwalton/devoid/pkg/brain"
+	"github.com/zachwalton/devoid/pkg/brain/templates"
+	"github.com/zachwalton/devoid/pkg/errors"
+
+	"github.com/charmbracelet/log"
+	"github.com/urfave/cli/v3"
+)
+
+// templateSets are the templates the command manages. Templates are named
+// by their path in a template directory, e.g. ast or summaries/ast.
+var templateSets = []templates.Set{templates.Prompts, brain.Summaries}
+
+// templatesCmd and its subcommands inherit --project-path from the main
+// command. Only dump needs it, when writing to the project's templates
+// directory; list leaves out the project's overrides without it.
+var templatesCmd = &cli.Command{
+	Name:  "templates",
+	Usage: "Customize the prompt and summary templates. Templates in .devoid/templates in the project, then devoid/templates in the user config directory, override the built-in ones with the same name",
+	Commands: []*cli.Command{
+		{
+			Name:      "dump",
+			Usage:     "Write the built-in templates to a directory, to start customizing them",
+			ArgsUsage: "[template...]: the templates to write. Defaults to all of them",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "output",
+					Usage: "Directory to write the templates to. Defaults to the project's templates directory",
+				},
+				&cli.BoolFlag{
+					Name:  "user",
+					Usage: "When true, the templates are written to the user's templates directory instead of the project's",
+				},
+				&cli.BoolFlag{
+					Name:  "force",
+					Usage: "When true, templates that already exist in the directory are overwritten",
+				},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				var dir string
+				switch {
+				case cmd.String("output") != "":
+					dir = cmd.String("output")
+				case cmd.Bool("user"):
+					userDir, err := templates.UserDir()
+					if err != nil {
+						return fmt.Errorf("could not find the user config directory: %w", err)
+					}
+					dir = userDir
+				default:
+					projectPath, err := requireProjectPath(cmd)
+					if err != nil {
+						return err
+					}
+					dir = templates.ProjectDir(projectPath)
+				}
+				type entry struct {
+					set  templates.Set
+					name string
+				}
+				var all []entry
+				var known []string
+				for _, set := range templateSets {
+					for _, name := range set.Names {
+						all = append(all, entry{set, name})
+						known = append(known, set.Qualified(name))
+					}
+				}
+				selected := all
+				if names := cmd.Args().Slice(); len(names) > 0 {
+					selected = nil
+					for _, name := range names {
+						i := slices.Index(known, name)
+						if i < 0 {
+							return fmt.Errorf("%w: %q, expected one of %v", errors.ErrUnknownTemplate, name, known)
+						}
+						selected = append(selected, all[i])
+					}
+				}
+
+				for _, t := range selected {
+					src, err := t.set.Default(t.name)
+					if err != nil {
+						return err
+					}
+					path := t.set.Path(dir, t.name)
+					if _, err := os.Stat(path); err == nil && !cmd.Bool("force") {
+						return fmt.Errorf("%w: %s, pass --force to replace it", errors.ErrOverwrite, path)
+					} else if err != nil && !goerrors.Is(err, fs.ErrNotExist) {
+						return err
+					}
+					if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+						return err
+					}
+					if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+						return err
+					}
+					log.Info("wrote template", "template", t.set.Qualified(t.name), "path", path)
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "list",
+			Usage: "List the templates and where each one is loaded from",
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				dirs := templates.Dirs(cmd.String("project-path"))
+				for _, set := range templateSets {
+					lib, err := set.Load(dirs...)
+					if err != nil {
+						return err
+					}
+					for _, name := range set.Names {
+						fmt.Printf("%-18s %s\n", set.Qualified(name), lib.Origin(name))
+					}
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "vars",
+			Usage: "List the variables available to templates",
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				for i, set := range templateSets {
+					if i > 0 {
+						fmt.Println()
+					}
+					var names []string
+					for _, name := range set.Names {
+						if name != set.Base {
+							names = append(names, set.Qualified(name))
+						}
+					}
+					fmt.Printf("%s:\n", strings.Join(names, ", "))
+					for _, v := range set.Variables {
+						fmt.Printf("  %-18s %s\n", v.Name, v.Description)
+					}
+				}
+				return nil
+			},
+		},
+	},
+}
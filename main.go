@@ -4,10 +4,14 @@ import (
 	"context"
 	"os"
 
+	"github.com/charmbracelet/log"
+
 	"github.com/zachwalton/devoid/cmd"
 	_ "github.com/zachwalton/devoid/pkg/tui"
 )
 
 func main() {
-	(cmd.Cmd).Run(context.Background(), os.Args)
+	if err := (cmd.Cmd).Run(context.Background(), os.Args); err != nil {
+		log.Fatal(err)
+	}
 }
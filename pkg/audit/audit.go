@@ -0,0 +1,333 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zachwalton/devoid/pkg/brain"
+	"github.com/zachwalton/devoid/pkg/errors"
+	"github.com/zachwalton/devoid/pkg/workspace"
+)
+
+// SessionsDir is where session logs are kept, relative to the project.
+const SessionsDir = ".devoid/sessions"
+
+const (
+	EventSessionStart = "session_start"
+	EventSessionEnd   = "session_end"
+	EventRequest      = "request"
+	EventResponse     = "response"
+	EventValidation   = "validation_error"
+	EventDecision     = "decision"
+	EventCommand      = "command"
+	EventWrite        = "write"
+	EventError        = "error"
+)
+
+// Decisions recorded when the user changes a result, in addition to the
+// menu choice that led to it.
+const (
+	DecisionReview        = "review"
+	DecisionChangeRequest = "change request"
+	DecisionEdit          = "edit"
+	DecisionRevert        = "revert"
+	DecisionAnswers       = "answers"
+)
+
+type (
+	// Log is the audit log for a single session. A nil *Log discards
+	// everything, so callers don't need to check whether logging is enabled.
+	Log struct {
+		mu      sync.Mutex
+		session string
+		path    string
+		f       *os.File
+	}
+
+	// Event is a single line in the audit log. Only the fields relevant to the
+	// event's type are set.
+	Event struct {
+		Time      time.Time `json:"time"`
+		Session   string    `json:"session"`
+		Type      string    `json:"type"`
+		Stage     string    `json:"stage,omitempty"`
+		Iteration int       `json:"iteration,omitempty"`
+
+		// Requests to the model.
+		Model      string `json:"model,omitempty"`
+		Prompt     string `json:"prompt,omitempty"`
+		SystemHash string `json:"system_hash,omitempty"`
+		Schema     string `json:"schema,omitempty"`
+
+		// Responses from the model.
+		Response string          `json:"response,omitempty"`
+		Payload  json.RawMessage `json:"payload,omitempty"`
+
+		Error    string           `json:"error,omitempty"`
+		Decision string           `json:"decision,omitempty"`
+		Detail   string           `json:"detail,omitempty"`
+		Command  *brain.Command   `json:"command,omitempty"`
+		Write    *workspace.Write `json:"write,omitempty"`
+		// Answers are the user's answers to the model's questions.
+		Answers []brain.Answer `json:"answers,omitempty"`
+	}
+
+	// Session is a session log on disk.
+	Session struct {
+		ID     string
+		Path   string
+		Start  time.Time
+		Events int
+	}
+
+	// Filter selects events. Empty fields match everything.
+	Filter struct {
+		Stage string
+		Types []string
+		Since time.Time
+		// Contains matches events with the string anywhere in their JSON.
+		Contains string
+	}
+)
+
+// Open starts a new session log in the project.
+func Open(projectPath string) (*Log, error) {
+	dir := filepath.Join(projectPath, SessionsDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	id := time.Now().UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix)
+	path := filepath.Join(dir, id+".jsonl")
+	// Logs contain prompts and model output, so they're only readable by the
+	// user.
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &Log{session: id, path: path, f: f}, nil
+}
+
+func (l *Log) Path() string {
+	if l == nil {
+		return ""
+	}
+	return l.path
+}
+
+// Record appends an event to the log, filling in its time and session.
+func (l *Log) Record(e Event) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return nil
+	}
+	e.Session = l.session
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = l.f.Write(append(b, '\n'))
+	return err
+}
+
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
+
+// Hash returns a short, stable identifier for a system template, so requests
+// can be compared without storing every template in full.
+func Hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Sessions returns the session logs in the project, oldest first.
+func Sessions(projectPath string) ([]Session, error) {
+	dir := filepath.Join(projectPath, SessionsDir)
+	entries, err := os.ReadDir(dir)
+	if goerrors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var sessions []Session
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".jsonl" {
+			continue
+		}
+		id := strings.TrimSuffix(e.Name(), ".jsonl")
+		s := Session{ID: id, Path: filepath.Join(dir, e.Name())}
+		events, err := Read(s.Path)
+		if err != nil {
+			return nil, err
+		}
+		s.Events = len(events)
+		if len(events) > 0 {
+			s.Start = events[0].Time
+		}
+		sessions = append(sessions, s)
+	}
+	// IDs start with a UTC timestamp, so they sort chronologically.
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].ID < sessions[j].ID })
+	return sessions, nil
+}
+
+// Find returns the session with the given ID, or the most recent session if
+// id is empty. Unique ID prefixes are accepted.
+func Find(projectPath, id string) (*Session, error) {
+	sessions, err := Sessions(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, fmt.Errorf("%w: no sessions in %s", errors.ErrNoSession, projectPath)
+	}
+	if id == "" {
+		return &sessions[len(sessions)-1], nil
+	}
+	var match *Session
+	for i, s := range sessions {
+		if !strings.HasPrefix(s.ID, id) {
+			continue
+		}
+		if match != nil {
+			return nil, fmt.Errorf("%w: %q matches more than one session", errors.ErrNoSession, id)
+		}
+		match = &sessions[i]
+	}
+	if match == nil {
+		return nil, fmt.Errorf("%w: %q", errors.ErrNoSession, id)
+	}
+	return match, nil
+}
+
+// Read returns every event in a session log. A truncated final line, e.g.
+// from a session that crashed mid-write, is ignored.
+func Read(path string) ([]Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var events []Event
+	s := bufio.NewScanner(f)
+	s.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; s.Scan(); line++ {
+		if len(strings.TrimSpace(s.Text())) == 0 {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			if !s.Scan() {
+				break
+			}
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		events = append(events, e)
+	}
+	return events, s.Err()
+}
+
+// Match returns true if the event passes the filter.
+func (f Filter) Match(e Event) bool {
+	if f.Stage != "" && e.Stage != f.Stage {
+		return false
+	}
+	if len(f.Types) > 0 {
+		found := false
+		for _, t := range f.Types {
+			if e.Type == t {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if f.Contains != "" {
+		b, _ := json.Marshal(e)
+		if !strings.Contains(strings.ToLower(string(b)), strings.ToLower(f.Contains)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Summary describes the event in a single line.
+func (e Event) Summary() string {
+	switch e.Type {
+	case EventSessionStart:
+		return fmt.Sprintf("model=%s prompt=%s", e.Model, oneLine(e.Prompt))
+	case EventRequest:
+		return fmt.Sprintf("model=%s system=%s prompt=%s", e.Model, e.SystemHash, oneLine(e.Prompt))
+	case EventResponse:
+		if e.Error != "" {
+			return fmt.Sprintf("%d bytes, error: %s", len(e.Response), oneLine(e.Error))
+		}
+		return fmt.Sprintf("%d bytes", len(e.Response))
+	case EventDecision:
+		if e.Detail != "" {
+			return fmt.Sprintf("%s: %s", e.Decision, oneLine(e.Detail))
+		}
+		return e.Decision
+	case EventCommand:
+		if e.Command == nil {
+			return ""
+		}
+		return fmt.Sprintf("%s (%s, exit %d): %s", e.Command.Status, e.Command.Action, e.Command.ExitCode, e.Command.Command)
+	case EventWrite:
+		if e.Write == nil {
+			return ""
+		}
+		return fmt.Sprintf("%s %s (%d bytes, sha256 %s)", e.Write.Action, e.Write.Path, e.Write.Bytes, e.Write.SHA256[:min(len(e.Write.SHA256), 12)])
+	}
+	if e.Error != "" {
+		return oneLine(e.Error)
+	}
+	return oneLine(e.Detail)
+}
+
+func oneLine(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > 100 {
+		s = s[:97] + "..."
+	}
+	return s
+}
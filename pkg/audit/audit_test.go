@@ -0,0 +1,192 @@
+package audit
+
+import (
+	goerrors "errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/zachwalton/devoid/pkg/errors"
+)
+
+func TestRecord(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []Event{
+		{Type: EventSessionStart, Model: "m", Prompt: "build an app"},
+		{Type: EventDecision, Stage: "initial", Decision: "accept", Detail: "changed"},
+	} {
+		if err := l.Record(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Recording after closing is a no-op.
+	if err := l.Record(Event{Type: EventError}); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := Read(l.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Read() returned %d events, want 2", len(events))
+	}
+	for _, e := range events {
+		if e.Session == "" || e.Time.IsZero() {
+			t.Errorf("event %+v is missing its session or time", e)
+		}
+	}
+	if events[1].Decision != "accept" || events[1].Detail != "changed" {
+		t.Errorf("events[1] = %+v", events[1])
+	}
+	info, err := os.Stat(l.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("log permissions = %o, want 600", perm)
+	}
+}
+
+func TestNilLog(t *testing.T) {
+	var l *Log
+	if err := l.Record(Event{Type: EventError}); err != nil {
+		t.Errorf("Record() error = %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if l.Path() != "" {
+		t.Errorf("Path() = %q, want empty", l.Path())
+	}
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		events  int
+		wantErr bool
+	}{
+		{
+			name:    "complete",
+			content: `{"type":"session_start"}` + "\n" + `{"type":"session_end"}` + "\n",
+			events:  2,
+		},
+		{
+			name:    "blank lines",
+			content: `{"type":"session_start"}` + "\n\n" + `{"type":"session_end"}` + "\n",
+			events:  2,
+		},
+		{
+			name:    "truncated final line",
+			content: `{"type":"session_start"}` + "\n" + `{"type":"resp`,
+			events:  1,
+		},
+		{
+			name:    "truncated final line with a newline",
+			content: `{"type":"session_start"}` + "\n" + `{"type":"resp` + "\n",
+			events:  1,
+		},
+		{
+			name:    "corrupt line in the middle",
+			content: `{"type":"session_start"}` + "\n" + `{"type":"resp` + "\n" + `{"type":"session_end"}` + "\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := filepath.Join(t.TempDir(), "session.jsonl")
+			if err := os.WriteFile(p, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			events, err := Read(p)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Read() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(events) != tt.events {
+				t.Errorf("Read() returned %d events, want %d", len(events), tt.events)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Find(dir, ""); !goerrors.Is(err, errors.ErrNoSession) {
+		t.Errorf("Find() error = %v, want %v", err, errors.ErrNoSession)
+	}
+	sessions := filepath.Join(dir, SessionsDir)
+	if err := os.MkdirAll(sessions, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"20260101T000000Z-aaaaaa", "20260102T000000Z-bbbbbb", "20260102T000000Z-bccccc"} {
+		if err := os.WriteFile(filepath.Join(sessions, id+".jsonl"), []byte(`{"type":"session_start"}`+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		id      string
+		want    string
+		wantErr bool
+	}{
+		{id: "", want: "20260102T000000Z-bccccc"},
+		{id: "20260101", want: "20260101T000000Z-aaaaaa"},
+		{id: "20260102T000000Z-bb", want: "20260102T000000Z-bbbbbb"},
+		{id: "20260102", wantErr: true},
+		{id: "2025", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			s, err := Find(dir, tt.id)
+			if tt.wantErr {
+				if !goerrors.Is(err, errors.ErrNoSession) {
+					t.Errorf("Find(%q) error = %v, want %v", tt.id, err, errors.ErrNoSession)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.ID != tt.want || s.Events != 1 {
+				t.Errorf("Find(%q) = %+v, want %s with 1 event", tt.id, s, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	now := time.Now()
+	e := Event{Time: now, Type: EventDecision, Stage: "ast", Decision: "accept", Detail: "Renamed the Server"}
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{name: "empty", want: true},
+		{name: "stage", filter: Filter{Stage: "ast"}, want: true},
+		{name: "other stage", filter: Filter{Stage: "code"}},
+		{name: "type", filter: Filter{Types: []string{EventCommand, EventDecision}}, want: true},
+		{name: "other type", filter: Filter{Types: []string{EventCommand}}},
+		{name: "since", filter: Filter{Since: now.Add(-time.Minute)}, want: true},
+		{name: "too old", filter: Filter{Since: now.Add(time.Minute)}},
+		{name: "contains ignores case", filter: Filter{Contains: "renamed the server"}, want: true},
+		{name: "doesn't contain", filter: Filter{Contains: "client"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(e); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
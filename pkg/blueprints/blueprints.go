@@ -0,0 +1,208 @@
+package blueprints
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/zachwalton/devoid/pkg/errors"
+)
+
+// Unset is the blueprint name the model uses when no blueprint fits.
+const Unset = "unset"
+
+//go:embed library/*.yaml
+var library embed.FS
+
+// nameRe matches project names that are safe to put in bootstrap commands and
+// paths as they are, e.g. go mod init {{.Name}}.
+var nameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/-]*$`)
+
+type (
+	// Blueprint is a canonical project skeleton for a language/framework pair.
+	// String fields in Bootstrap and Layout may reference {{.Name}} and
+	// {{.Package}}, which are filled in from the project name.
+	Blueprint struct {
+		Name         string   `yaml:"name"`
+		Description  string   `yaml:"description"`
+		Language     string   `yaml:"language"`
+		Framework    string   `yaml:"framework"`
+		Architecture string   `yaml:"architecture"`
+		Test         string   `yaml:"test"`
+		TestCommand  string   `yaml:"test_command"`
+		Bootstrap    []string `yaml:"bootstrap"`
+		Layout       []File   `yaml:"layout"`
+	}
+
+	File struct {
+		Path      string   `yaml:"path"`
+		Purpose   string   `yaml:"purpose"`
+		DependsOn []string `yaml:"depends_on"`
+	}
+
+	Library struct {
+		blueprints map[string]*Blueprint
+	}
+
+	vars struct {
+		Name    string
+		Package string
+	}
+)
+
+// Load returns the embedded blueprints, plus any *.yaml or *.yml blueprints
+// found in dir. Blueprints in dir replace embedded ones with the same name.
+func Load(dir string) (*Library, error) {
+	l := &Library{blueprints: map[string]*Blueprint{}}
+	if err := l.load(library, "library"); err != nil {
+		return nil, err
+	}
+	if dir == "" {
+		return l, nil
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("could not read blueprints directory: %w", err)
+	}
+	if err := l.load(os.DirFS(dir), "."); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *Library) load(fsys fs.FS, root string) error {
+	entries, err := fs.ReadDir(fsys, root)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		b, err := fs.ReadFile(fsys, filepath.ToSlash(filepath.Join(root, e.Name())))
+		if err != nil {
+			return err
+		}
+		var bp Blueprint
+		if err := yaml.Unmarshal(b, &bp); err != nil {
+			return fmt.Errorf("%w: %s: %s", errors.ErrInvalidBlueprint, e.Name(), err)
+		}
+		if bp.Name == "" {
+			bp.Name = strings.TrimSuffix(e.Name(), ext)
+		}
+		if bp.Name == Unset {
+			return fmt.Errorf("%w: %s: %q is a reserved name", errors.ErrInvalidBlueprint, e.Name(), Unset)
+		}
+		l.blueprints[bp.Name] = &bp
+	}
+	return nil
+}
+
+func (l *Library) Names() []string {
+	var names []string
+	for name := range l.blueprints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the named blueprint rendered for the given project name, which
+// may only contain letters, digits, '.', '_', '/' and '-'.
+func (l *Library) Get(name, project string) (*Blueprint, error) {
+	bp, ok := l.blueprints[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", errors.ErrUnknownBlueprint, name)
+	}
+	if !nameRe.MatchString(project) {
+		return nil, fmt.Errorf("%w: %q must start with a letter or digit and only contain letters, digits, '.', '_', '/' and '-'", errors.ErrInvalidName, project)
+	}
+	return bp.render(project)
+}
+
+// Catalog renders the library as a list the model can choose from.
+func (l *Library) Catalog() string {
+	var b strings.Builder
+	for _, name := range l.Names() {
+		bp := l.blueprints[name]
+		fmt.Fprintf(&b, "- %s: %s (language: %s, framework: %s, test: %s)\n", bp.Name, bp.Description, bp.Language, bp.Framework, bp.Test)
+	}
+	return b.String()
+}
+
+// Skeleton renders the blueprint's layout and setup for use in later stages.
+func (bp *Blueprint) Skeleton() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Blueprint: %s (%s)\n", bp.Name, bp.Description)
+	b.WriteString("Layout:\n")
+	for _, f := range bp.Layout {
+		fmt.Fprintf(&b, "  %s: %s", f.Path, f.Purpose)
+		if len(f.DependsOn) > 0 {
+			fmt.Fprintf(&b, " (depends on %s)", strings.Join(f.DependsOn, ", "))
+		}
+		b.WriteString("\n")
+	}
+	if len(bp.Bootstrap) > 0 {
+		fmt.Fprintf(&b, "Bootstrap commands: %s\n", strings.Join(bp.Bootstrap, "; "))
+	}
+	if bp.TestCommand != "" {
+		fmt.Fprintf(&b, "Test command: %s\n", bp.TestCommand)
+	}
+	return b.String()
+}
+
+func (bp *Blueprint) render(project string) (*Blueprint, error) {
+	// Names may be module paths, so the package is their last element.
+	v := vars{
+		Name:    project,
+		Package: strings.NewReplacer("-", "_", ".", "_").Replace(strings.ToLower(path.Base(project))),
+	}
+	var err error
+	r := *bp
+	r.Bootstrap = make([]string, len(bp.Bootstrap))
+	for i, c := range bp.Bootstrap {
+		if r.Bootstrap[i], err = expand(c, v); err != nil {
+			return nil, err
+		}
+	}
+	r.Layout = make([]File, len(bp.Layout))
+	for i, f := range bp.Layout {
+		r.Layout[i] = File{Purpose: f.Purpose}
+		if r.Layout[i].Path, err = expand(f.Path, v); err != nil {
+			return nil, err
+		}
+		for _, dep := range f.DependsOn {
+			d, err := expand(dep, v)
+			if err != nil {
+				return nil, err
+			}
+			r.Layout[i].DependsOn = append(r.Layout[i].DependsOn, d)
+		}
+		if r.Layout[i].Purpose, err = expand(f.Purpose, v); err != nil {
+			return nil, err
+		}
+	}
+	return &r, nil
+}
+
+func expand(s string, v vars) (string, error) {
+	t, err := template.New("blueprint").Option("missingkey=error").Parse(s)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", errors.ErrInvalidBlueprint, err)
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, v); err != nil {
+		return "", fmt.Errorf("%w: %s", errors.ErrInvalidBlueprint, err)
+	}
+	return b.String(), nil
+}
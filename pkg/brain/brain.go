@@ -1,76 +1,150 @@
 package brain
 
 import (
-	"bytes"
-	"text/template"
+	"encoding/json"
+
+	"github.com/zachwalton/devoid/pkg/brain/templates"
+	"github.com/zachwalton/devoid/pkg/secrets"
+	"github.com/zachwalton/devoid/pkg/validate"
+	"github.com/zachwalton/devoid/pkg/workspace"
+)
+
+const (
+	CommandRan     = "ran"
+	CommandFailed  = "failed"
+	CommandSkipped = "skipped"
+	CommandBlocked = "blocked"
+)
+
+const (
+	ActionCreate = "create"
+	ActionModify = "modify"
+)
+
+const (
+	QuestionText   = "text"
+	QuestionChoice = "choice"
+	QuestionYesNo  = "yes_no"
 )
 
 type StagePayload struct {
-	Meta         MetaPayload         `json:"meta"`
-	StateMachine StateMachinePayload `json:"state_machine"`
+	Meta      MetaPayload       `json:"meta"`
+	AST       []FileNode        `json:"ast,omitempty"`
+	Files     []File            `json:"files,omitempty"`
+	Bootstrap []string          `json:"bootstrap,omitempty"`
+	Commands  []Command         `json:"-"`
+	Writes    []workspace.Write `json:"-"`
+	// Findings are likely secrets that were redacted from the payload.
+	Findings []secrets.Finding `json:"-"`
+	// Diagnostics are problems static checks found in the generated files
+	// that the model didn't fix within the retry budget.
+	Diagnostics  []validate.Diagnostic `json:"-"`
+	StateMachine StateMachinePayload   `json:"state_machine"`
 }
 
 type MetaPayload struct {
-	Name         string `json:"name"`
-	Language     string `json:"language"`
-	Test         string `json:"test"`
-	Framework    string `json:"framework"`
-	Architecture string `json:"architecture"`
-	Description  string `json:"description"`
-	CurrentStage string `json:"-"`
-	ProjectPath  string `json:"-"`
+	Name           string `json:"name"`
+	Language       string `json:"language"`
+	Test           string `json:"test"`
+	Framework      string `json:"framework"`
+	Architecture   string `json:"architecture"`
+	Description    string `json:"description"`
+	Database       string `json:"database"`
+	Blueprint      string `json:"blueprint"`
+	TestCommand    string `json:"test_command,omitempty"`
+	License        string `json:"license"`
+	CI             string `json:"ci"`
+	Deployment     string `json:"deployment"`
+	RuntimeVersion string `json:"runtime_version"`
+	PackageManager string `json:"package_manager"`
+	CodeStyle      string `json:"code_style"`
+	CurrentStage   string `json:"-"`
+	ProjectPath    string `json:"-"`
+	Existing       bool   `json:"-"`
+	// Custom holds the values of the custom fields declared in the config,
+	// by name.
+	Custom map[string]string `json:"custom,omitempty"`
 }
 
-type StateMachinePayload struct {
-	Next           string   `json:"next"`
-	Final          bool     `json:"final"`
-	ModifiedResult bool     `json:"modified_result"`
-	Description    string   `json:"description"`
-	Questions      []string `json:"questions"`
+// FileNode is a single file in the proposed codebase, along with the files it
+// depends on. Together the nodes form an adjacency list.
+type FileNode struct {
+	Path      string   `json:"path"`
+	Purpose   string   `json:"purpose"`
+	Action    string   `json:"action"`
+	DependsOn []string `json:"depends_on"`
 }
 
-func (p *StagePayload) Markdown(stage, projectPath string) string {
-	p.Meta.CurrentStage = stage
-	p.Meta.ProjectPath = projectPath
-
-	t, _ := template.New("markdown").Parse(`
-# {{.Meta.Name}}
-
-This is a summary of current status. When you're ready, press ` + "`" + `q` + "`" + ` and you'll be presented with some options.
-
-{{ if .StateMachine.ModifiedResult }}
-## Changes Applied
-
-The changes requested have been applied as follows:
-
-{{.StateMachine.Description}}
-
-{{ end }}
-{{ if eq .Meta.CurrentStage "initial" }}
-## Core Design Choices
+// Command is a proposed command along with its safety policy classification
+// and, once the stage is applied, what happened when it ran.
+type Command struct {
+	Command string `json:"command"`
+	Action  string `json:"action"`
+	Reason  string `json:"reason"`
+	// Status is one of CommandRan, CommandFailed, CommandSkipped or
+	// CommandBlocked, or empty if the stage hasn't been applied.
+	Status    string `json:"status,omitempty"`
+	ExitCode  int    `json:"exit_code"`
+	Sandboxed bool   `json:"sandboxed,omitempty"`
+	Output    string `json:"output,omitempty"`
+}
 
-> {{.Meta.Description}}
+// File is the generated content for a node in the AST.
+type File struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
 
-The following are the high-level design choices for the "{{.Meta.Name}}" app. These may evolve in subsequent stages.
+type StateMachinePayload struct {
+	Next           string     `json:"next"`
+	Final          bool       `json:"final"`
+	ModifiedResult bool       `json:"modified_result"`
+	Description    string     `json:"description"`
+	Questions      []Question `json:"questions"`
+}
 
-* *Language:* {{.Meta.Language}}
-* *Test Strategy:* {{.Meta.Test}}
-* *Framework(s):* {{.Meta.Framework}}
-* *Architecture(s):* {{.Meta.Architecture}}
-{{ end }}
+// Question is a clarifying question from the model. Type is one of
+// QuestionText, QuestionChoice with Options to choose from, or QuestionYesNo.
+// Default is the answer the model suggests, if any.
+type Question struct {
+	Question string   `json:"question"`
+	Type     string   `json:"type"`
+	Options  []string `json:"options,omitempty"`
+	Default  string   `json:"default,omitempty"`
+}
 
-{{ if gt (len .StateMachine.Questions) 0 }}
-### Clarity Requested
+// UnmarshalJSON also accepts a plain string, which is how questions were
+// returned before they were typed, as a free text question.
+func (q *Question) UnmarshalJSON(b []byte) error {
+	var text string
+	if err := json.Unmarshal(b, &text); err == nil {
+		*q = Question{Question: text, Type: QuestionText}
+		return nil
+	}
+	type question Question
+	var v question
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*q = Question(v)
+	if q.Type == "" {
+		q.Type = QuestionText
+	}
+	return nil
+}
 
-devoid has some questions for you:
+// Answer is the user's answer to a question. Skipped answers are left for
+// the model to decide.
+type Answer struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer,omitempty"`
+	Skipped  bool   `json:"skipped,omitempty"`
+}
 
-{{ range $question := .StateMachine.Questions }}
-* {{$question}} 
-{{ end }}
-{{ end }}
-    `,
-	)
-	var b bytes.Buffer
-	t.Execute(&b, p)
-	return b.String()
+// Markdown renders the summary of the stage's result with the named template
+// from summaries, which is loaded by LoadSummaries.
+func (p *StagePayload) Markdown(summaries *templates.Library, name, stage, projectPath string) (string, error) {
+	p.Meta.CurrentStage = stage
+	p.Meta.ProjectPath = projectPath
+	return summaries.Render(name, p)
 }
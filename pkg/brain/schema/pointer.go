@@ -0,0 +1,96 @@
+package schema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	pointerEscaper   = strings.NewReplacer("~", "~0", "/", "~1")
+	pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+)
+
+// Pointer appends tokens to the JSON pointer base, escaping them as needed.
+func Pointer(base string, tokens ...string) string {
+	var b strings.Builder
+	b.WriteString(base)
+	for _, t := range tokens {
+		b.WriteString("/" + pointerEscaper.Replace(t))
+	}
+	return b.String()
+}
+
+// Tokens splits a JSON pointer into its unescaped reference tokens.
+func Tokens(pointer string) []string {
+	if pointer == "" {
+		return nil
+	}
+	tokens := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, t := range tokens {
+		tokens[i] = pointerUnescaper.Replace(t)
+	}
+	return tokens
+}
+
+// Get returns the value at pointer in doc, as decoded by encoding/json.
+func Get(doc any, pointer string) (any, bool) {
+	for _, token := range Tokens(pointer) {
+		switch v := doc.(type) {
+		case map[string]any:
+			value, ok := v[token]
+			if !ok {
+				return nil, false
+			}
+			doc = value
+		case []any:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			doc = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// Set sets the value at pointer in doc, creating objects along the way. The
+// parent of the value must be an object, or an array that already has the
+// index.
+func Set(doc any, pointer string, value any) error {
+	tokens := Tokens(pointer)
+	if len(tokens) == 0 {
+		return fmt.Errorf("can't replace the whole document")
+	}
+	for i, token := range tokens {
+		last := i == len(tokens)-1
+		switch v := doc.(type) {
+		case map[string]any:
+			if last {
+				v[token] = value
+				return nil
+			}
+			next, ok := v[token]
+			if !ok || next == nil {
+				next = map[string]any{}
+				v[token] = next
+			}
+			doc = next
+		case []any:
+			n, err := strconv.Atoi(token)
+			if err != nil || n < 0 || n >= len(v) {
+				return fmt.Errorf("%s: index %q is out of range", pointer, token)
+			}
+			if last {
+				v[n] = value
+				return nil
+			}
+			doc = v[n]
+		default:
+			return fmt.Errorf("%s: %q isn't an object or array", pointer, Pointer("", tokens[:i]...))
+		}
+	}
+	return nil
+}
@@ -2,11 +2,23 @@ package schema
 
 import (
 	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/zachwalton/devoid/pkg/config"
+	"github.com/zachwalton/devoid/pkg/errors"
 )
 
 var (
 	stateMachineRequires = []string{"description", "next", "final", "questions"}
-	metaRequires         = []string{"language", "test", "framework", "architecture", "description", "database"}
+	metaRequires         = []string{"language", "test", "framework", "architecture", "description", "database", "blueprint", "license", "ci", "deployment", "runtime_version", "package_manager", "code_style"}
+	fileNodeRequires     = []string{"path", "purpose", "action", "depends_on"}
+	fileRequires         = []string{"path", "content"}
+	questionRequires     = []string{"question", "type"}
+
+	// customFieldName is the form of custom metadata field names.
+	customFieldName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
 )
 
 type Schema struct {
@@ -18,7 +30,10 @@ type Schema struct {
 }
 
 type SchemaProperties struct {
-	Meta         *MetaProperties         `json:"meta"`
+	Meta         *MetaProperties         `json:"meta,omitempty"`
+	AST          *ASTProperties          `json:"ast,omitempty"`
+	Bootstrap    *Property               `json:"bootstrap,omitempty"`
+	Files        *FilesProperties        `json:"files,omitempty"`
 	StateMachine *StateMachineProperties `json:"state_machine"`
 }
 
@@ -30,13 +45,65 @@ type MetaProperties struct {
 }
 
 type MetaPropertyList struct {
-	Name         *Property `json:"name"`
-	Description  *Property `json:"description"`
-	Language     *Property `json:"language"`
-	Test         *Property `json:"test"`
-	Framework    *Property `json:"framework"`
-	Database     *Property `json:"database"`
-	Architecture *Property `json:"architecture"`
+	Name           *Property         `json:"name"`
+	Description    *Property         `json:"description"`
+	Language       *Property         `json:"language"`
+	Test           *Property         `json:"test"`
+	Framework      *Property         `json:"framework"`
+	Database       *Property         `json:"database"`
+	Architecture   *Property         `json:"architecture"`
+	Blueprint      *Property         `json:"blueprint"`
+	License        *Property         `json:"license"`
+	CI             *Property         `json:"ci"`
+	Deployment     *Property         `json:"deployment"`
+	RuntimeVersion *Property         `json:"runtime_version"`
+	PackageManager *Property         `json:"package_manager"`
+	CodeStyle      *Property         `json:"code_style"`
+	Custom         *CustomProperties `json:"custom,omitempty"`
+}
+
+// CustomProperties are the custom metadata fields declared in the config.
+type CustomProperties struct {
+	Type        string               `json:"type"`
+	Description string               `json:"description"`
+	Required    []string             `json:"required"`
+	Properties  map[string]*Property `json:"properties"`
+}
+
+type ASTProperties struct {
+	Type        string              `json:"type"`
+	Description string              `json:"description"`
+	Items       *FileNodeProperties `json:"items"`
+}
+
+type FileNodeProperties struct {
+	Type       string                `json:"type"`
+	Required   []string              `json:"required"`
+	Properties *FileNodePropertyList `json:"properties"`
+}
+
+type FileNodePropertyList struct {
+	Path      *Property `json:"path"`
+	Purpose   *Property `json:"purpose"`
+	Action    *Property `json:"action"`
+	DependsOn *Property `json:"depends_on"`
+}
+
+type FilesProperties struct {
+	Type        string          `json:"type"`
+	Description string          `json:"description"`
+	Items       *FileProperties `json:"items"`
+}
+
+type FileProperties struct {
+	Type       string            `json:"type"`
+	Required   []string          `json:"required"`
+	Properties *FilePropertyList `json:"properties"`
+}
+
+type FilePropertyList struct {
+	Path    *Property `json:"path"`
+	Content *Property `json:"content"`
 }
 
 type StateMachineProperties struct {
@@ -47,16 +114,37 @@ type StateMachineProperties struct {
 }
 
 type StateMachinePropertyList struct {
-	Description *Property `json:"description"`
-	Next        *Property `json:"next"`
-	Final       *Property `json:"final"`
-	Questions   *Property `json:"questions"`
+	Description *Property            `json:"description"`
+	Next        *Property            `json:"next"`
+	Final       *Property            `json:"final"`
+	Questions   *QuestionsProperties `json:"questions"`
+}
+
+type QuestionsProperties struct {
+	Type        string              `json:"type"`
+	Description string              `json:"description"`
+	Items       *QuestionProperties `json:"items"`
+	Default     interface{}         `json:"default"`
+}
+
+type QuestionProperties struct {
+	Type       string                `json:"type"`
+	Required   []string              `json:"required"`
+	Properties *QuestionPropertyList `json:"properties"`
+}
+
+type QuestionPropertyList struct {
+	Question *Property `json:"question"`
+	Type     *Property `json:"type"`
+	Options  *Property `json:"options"`
+	Default  *Property `json:"default"`
 }
 
 type Property struct {
 	Type        string      `json:"type"`
 	Description string      `json:"description"`
 	Items       *Item       `json:"items,omitempty"`
+	Enum        []string    `json:"enum,omitempty"`
 	Default     interface{} `json:"default"`
 }
 
@@ -79,26 +167,112 @@ func schemaDefault() *Schema {
 					Description: &Property{Type: "string", Description: "Description of changes made by the model for this inference", Default: ""},
 					Next:        &Property{Type: "string", Description: "Next phase to execute with this output as the next stage's input", Default: "scaffolding"},
 					Final:       &Property{Type: "boolean", Description: "True if this is the final stage for the project.", Default: true},
-					Questions:   &Property{Type: "array", Items: &Item{Type: "string"}, Description: "Put any clarifying questions here if needed. This should only be used to satisfy 'unset' fields. The questions are you asking the user for project clarification, not random stuff like asking about what algorithms to use that the user does not know", Default: []string{}},
+					Questions:   questionsDefault(),
 				},
 			},
 		},
 	}
 }
 
+func questionsDefault() *QuestionsProperties {
+	return &QuestionsProperties{
+		Type:        "array",
+		Description: "Put any clarifying questions here if needed. This should only be used to satisfy 'unset' fields. The questions are you asking the user for project clarification, not random stuff like asking about what algorithms to use that the user does not know",
+		Default:     []string{},
+		Items: &QuestionProperties{
+			Type:     "object",
+			Required: questionRequires,
+			Properties: &QuestionPropertyList{
+				Question: &Property{Type: "string", Description: "The question to ask the user.", Default: ""},
+				Type:     &Property{Type: "string", Enum: []string{"text", "choice", "yes_no"}, Description: "'choice' when the answer should be one of a few options, 'yes_no' for yes or no questions, and 'text' otherwise.", Default: "text"},
+				Options:  &Property{Type: "array", Items: &Item{Type: "string"}, Description: "The options to choose from when type is 'choice'. Empty otherwise.", Default: []string{}},
+				Default:  &Property{Type: "string", Description: "The answer you suggest, which the user can accept. For 'choice' it must be one of the options, and for 'yes_no' either 'yes' or 'no'. Empty if you have no suggestion.", Default: ""},
+			},
+		},
+	}
+}
+
 func metaDefault() *MetaProperties {
 	return &MetaProperties{
 		Type:        "object",
 		Description: "General characteristics for the codebase.",
 		Required:    metaRequires,
 		Properties: &MetaPropertyList{
-			Name:         &Property{Type: "string", Description: "Name of this project. Never use the default for this, you must always choose a name.", Default: "unset"},
-			Description:  &Property{Type: "string", Description: "Description of the project. Be concise but thorough in describing the various high-level approaches.", Default: "unset"},
-			Language:     &Property{Type: "string", Description: "The language that will be used for the codebase. Can be multiple languages depending on the project. When multiple languages are set, make it a comma-delimited list", Default: "unset"},
-			Test:         &Property{Type: "string", Description: "Preferences related to testing (unit, integration, etc.). When adopting more than one testing approach, e.g. both unit and integration, make it a comma-delimited list", Default: "unset"},
-			Framework:    &Property{Type: "string", Description: "Preferences related to frameworks (Ruby on Rails, Django, etc.). This is always a project-wide development framework and never something more specific like 'unittest'", Default: "unset"},
-			Database:     &Property{Type: "string", Description: "Preferences related to database usage. 'database' encompasses all types of stateful data, so it's acceptable to put both things like 'cassandra' and 'static json' here. Default to simple solutions like flat files unless specifically requested by the user", Default: "unset"},
-			Architecture: &Property{Type: "string", Description: "Preferences related to architecture (SOA, MVC, etc.).", Default: "unset"},
+			Name:           &Property{Type: "string", Description: "Name of this project. Never use the default for this, you must always choose a name.", Default: "unset"},
+			Description:    &Property{Type: "string", Description: "Description of the project. Be concise but thorough in describing the various high-level approaches.", Default: "unset"},
+			Language:       &Property{Type: "string", Description: "The language that will be used for the codebase. Can be multiple languages depending on the project. When multiple languages are set, make it a comma-delimited list", Default: "unset"},
+			Test:           &Property{Type: "string", Description: "Preferences related to testing (unit, integration, etc.). When adopting more than one testing approach, e.g. both unit and integration, make it a comma-delimited list", Default: "unset"},
+			Framework:      &Property{Type: "string", Description: "Preferences related to frameworks (Ruby on Rails, Django, etc.). This is always a project-wide development framework and never something more specific like 'unittest'", Default: "unset"},
+			Database:       &Property{Type: "string", Description: "Preferences related to database usage. 'database' encompasses all types of stateful data, so it's acceptable to put both things like 'cassandra' and 'static json' here. Default to simple solutions like flat files unless specifically requested by the user", Default: "unset"},
+			Architecture:   &Property{Type: "string", Description: "Preferences related to architecture (SOA, MVC, etc.).", Default: "unset"},
+			Blueprint:      &Property{Type: "string", Description: "Name of the blueprint from the provided list that best matches the project, or 'unset' if none of them fit.", Default: "unset"},
+			License:        &Property{Type: "string", Description: "SPDX identifier of the project's license, e.g. MIT or Apache-2.0. Only choose one if the user asks for it or the existing codebase has one", Default: "unset"},
+			CI:             &Property{Type: "string", Description: "CI provider that builds and tests the project, e.g. GitHub Actions or GitLab CI", Default: "unset"},
+			Deployment:     &Property{Type: "string", Description: "Container or deployment target, e.g. 'docker image on kubernetes', 'aws lambda' or 'binary release'", Default: "unset"},
+			RuntimeVersion: &Property{Type: "string", Description: "Minimum version of the language or runtime the project supports, e.g. 'go 1.22' or 'python 3.11'. When multiple languages are set, make it a comma-delimited list", Default: "unset"},
+			PackageManager: &Property{Type: "string", Description: "Package manager used for dependencies, e.g. go modules, npm, pnpm, poetry or cargo", Default: "unset"},
+			CodeStyle:      &Property{Type: "string", Description: "Formatter and linter conventions the code follows, e.g. gofmt, black and ruff, or prettier and eslint", Default: "unset"},
+		},
+	}
+}
+
+// customDefault returns the schema for custom metadata fields, or an
+// ErrInvalidConfig if any of them are invalid.
+func customDefault(fields []config.MetaField) (*CustomProperties, error) {
+	c := &CustomProperties{
+		Type:        "object",
+		Description: "Custom characteristics of the codebase that the user's team always decides.",
+		Required:    []string{},
+		Properties:  map[string]*Property{},
+	}
+	for i, f := range fields {
+		if !customFieldName.MatchString(f.Name) {
+			return nil, fmt.Errorf("%w: metadata -> fields -> %d: name must be lowercase letters, digits and underscores, got %q", errors.ErrInvalidConfig, i, f.Name)
+		}
+		if _, ok := c.Properties[f.Name]; ok {
+			return nil, fmt.Errorf("%w: metadata -> fields -> %d: %q is declared more than once", errors.ErrInvalidConfig, i, f.Name)
+		}
+		if f.Default != "" && len(f.Options) > 0 && !slices.Contains(f.Options, f.Default) {
+			return nil, fmt.Errorf("%w: metadata -> fields -> %d (%s): default %q isn't one of the options", errors.ErrInvalidConfig, i, f.Name, f.Default)
+		}
+		def := f.Default
+		if def == "" && len(f.Options) == 0 {
+			def = "unset"
+		}
+		c.Required = append(c.Required, f.Name)
+		c.Properties[f.Name] = &Property{Type: "string", Description: f.Description, Enum: f.Options, Default: def}
+	}
+	return c, nil
+}
+
+func astDefault() *ASTProperties {
+	return &ASTProperties{
+		Type:        "array",
+		Description: "Adjacency list of every file in the proposed codebase. Each entry is a file and the files it depends on.",
+		Items: &FileNodeProperties{
+			Type:     "object",
+			Required: fileNodeRequires,
+			Properties: &FileNodePropertyList{
+				Path:      &Property{Type: "string", Description: "Path of the file relative to the project directory. Never absolute and never containing '..'.", Default: ""},
+				Purpose:   &Property{Type: "string", Description: "Concise description of what the file is responsible for.", Default: ""},
+				Action:    &Property{Type: "string", Enum: []string{"create", "modify"}, Description: "'create' for new files, 'modify' for files that already exist in the project directory and should be edited in place.", Default: "create"},
+				DependsOn: &Property{Type: "array", Items: &Item{Type: "string"}, Description: "Relative paths of other files in this list that this file imports or otherwise depends on.", Default: []string{}},
+			},
+		},
+	}
+}
+
+func filesDefault() *FilesProperties {
+	return &FilesProperties{
+		Type:        "array",
+		Description: "The complete content of every file in the proposed codebase.",
+		Items: &FileProperties{
+			Type:     "object",
+			Required: fileRequires,
+			Properties: &FilePropertyList{
+				Path:    &Property{Type: "string", Description: "Path of the file relative to the project directory, exactly as it appears in the ast.", Default: ""},
+				Content: &Property{Type: "string", Description: "The complete content of the file. Never truncate or use placeholders like '...'.", Default: ""},
+			},
 		},
 	}
 }
@@ -109,8 +283,39 @@ func (s Schema) JSON() string {
 }
 
 func SchemaInitial() string {
+	s, _ := SchemaInitialWith(nil)
+	return s
+}
+
+// SchemaInitialWith returns the initial stage's schema with the custom
+// metadata fields declared in the config, or an ErrInvalidConfig if any of
+// them are invalid.
+func SchemaInitialWith(fields []config.MetaField) (string, error) {
 	s := schemaDefault()
 	s.Required = append(s.Required, "meta")
 	s.Properties.Meta = metaDefault()
+	if len(fields) > 0 {
+		custom, err := customDefault(fields)
+		if err != nil {
+			return "", err
+		}
+		s.Properties.Meta.Required = append(slices.Clone(s.Properties.Meta.Required), "custom")
+		s.Properties.Meta.Properties.Custom = custom
+	}
+	s.Properties.Bootstrap = &Property{Type: "array", Items: &Item{Type: "string"}, Description: "Shell commands to run in the project directory to bootstrap the project, e.g. 'go mod init example'. Leave empty when a blueprint is selected, since it provides its own.", Default: []string{}}
+	return s.JSON(), nil
+}
+
+func SchemaAST() string {
+	s := schemaDefault()
+	s.Required = append(s.Required, "ast")
+	s.Properties.AST = astDefault()
+	return s.JSON()
+}
+
+func SchemaCode() string {
+	s := schemaDefault()
+	s.Required = append(s.Required, "files")
+	s.Properties.Files = filesDefault()
 	return s.JSON()
 }
@@ -0,0 +1,275 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/zachwalton/devoid/pkg/errors"
+)
+
+// Draft07 is the only JSON schema draft Parse accepts.
+const Draft07 = "http://json-schema.org/draft-07/schema#"
+
+// Node is a parsed draft-07 JSON schema, limited to the validation keywords
+// for single types: schema composition and references aren't supported.
+type Node struct {
+	Schema      string           `json:"$schema"`
+	Type        string           `json:"type"`
+	Description string           `json:"description"`
+	Required    []string         `json:"required"`
+	Properties  map[string]*Node `json:"properties"`
+	// AdditionalProperties is false, or a schema for properties that aren't
+	// in Properties. It's nil when they're allowed.
+	AdditionalProperties *Node    `json:"additionalProperties"`
+	Items                *Node    `json:"items"`
+	Enum                 []any    `json:"enum"`
+	Const                any      `json:"const"`
+	Default              any      `json:"default"`
+	MinLength            *int     `json:"minLength"`
+	MaxLength            *int     `json:"maxLength"`
+	Pattern              string   `json:"pattern"`
+	Minimum              *float64 `json:"minimum"`
+	Maximum              *float64 `json:"maximum"`
+	MinItems             *int     `json:"minItems"`
+	MaxItems             *int     `json:"maxItems"`
+	UniqueItems          bool     `json:"uniqueItems"`
+
+	// forbidden is set for a false schema, which no value satisfies.
+	forbidden bool
+	pattern   *regexp.Regexp
+}
+
+// Violation is a value in a document that doesn't satisfy its schema.
+type Violation struct {
+	// Pointer is the JSON pointer of the value, e.g. /meta/name.
+	Pointer string
+	Message string
+}
+
+func (v Violation) String() string {
+	pointer := v.Pointer
+	if pointer == "" {
+		pointer = "/"
+	}
+	return pointer + ": " + v.Message
+}
+
+// Parse parses a schema as returned by SchemaInitial, SchemaAST and
+// SchemaCode.
+func Parse(s string) (*Node, error) {
+	var n Node
+	if err := json.Unmarshal([]byte(s), &n); err != nil {
+		return nil, fmt.Errorf("could not parse schema: %w", err)
+	}
+	if n.Schema != "" && n.Schema != Draft07 {
+		return nil, fmt.Errorf("could not parse schema: unsupported draft %q", n.Schema)
+	}
+	if err := n.compile(""); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// UnmarshalJSON also accepts the boolean schemas true, which any value
+// satisfies, and false, which none do.
+func (n *Node) UnmarshalJSON(b []byte) error {
+	var allowed bool
+	if err := json.Unmarshal(b, &allowed); err == nil {
+		*n = Node{forbidden: !allowed}
+		return nil
+	}
+	type node Node
+	return json.Unmarshal(b, (*node)(n))
+}
+
+// compile compiles the patterns in the schema.
+func (n *Node) compile(pointer string) error {
+	if n == nil {
+		return nil
+	}
+	if n.Pattern != "" {
+		re, err := regexp.Compile(n.Pattern)
+		if err != nil {
+			return fmt.Errorf("could not parse schema: %s/pattern: %w", pointer, err)
+		}
+		n.pattern = re
+	}
+	for key, p := range n.Properties {
+		if err := p.compile(Pointer(pointer, "properties", key)); err != nil {
+			return err
+		}
+	}
+	if err := n.AdditionalProperties.compile(pointer + "/additionalProperties"); err != nil {
+		return err
+	}
+	return n.Items.compile(pointer + "/items")
+}
+
+// Check parses response and validates it against the schema. Responses that
+// don't satisfy it return an ErrRecoverable listing every violation, so the
+// model can be told exactly what to fix.
+func (n *Node) Check(response string) error {
+	var doc any
+	if err := json.Unmarshal([]byte(response), &doc); err != nil {
+		return fmt.Errorf("%w: the response isn't valid JSON: %s", errors.ErrRecoverable, err)
+	}
+	violations := n.Validate(doc)
+	if len(violations) == 0 {
+		return nil
+	}
+	lines := make([]string, len(violations))
+	for i, v := range violations {
+		lines[i] = "- " + v.String()
+	}
+	return fmt.Errorf("%w: the response doesn't match the schema:\n%s", errors.ErrRecoverable, strings.Join(lines, "\n"))
+}
+
+// Lookup returns the schema for the value at pointer, or nil if the schema
+// doesn't describe it. Array indexes match the schema's items.
+func (n *Node) Lookup(pointer string) *Node {
+	for _, token := range Tokens(pointer) {
+		switch {
+		case n == nil:
+			return nil
+		case n.Type == "array":
+			n = n.Items
+		default:
+			n = n.Properties[token]
+		}
+	}
+	return n
+}
+
+// Validate returns every value in doc, as decoded by encoding/json, that
+// doesn't satisfy the schema, in document order with object keys sorted.
+func (n *Node) Validate(doc any) []Violation {
+	var violations []Violation
+	n.validate("", doc, &violations)
+	return violations
+}
+
+func (n *Node) validate(pointer string, v any, violations *[]Violation) {
+	if n == nil {
+		return
+	}
+	if n.forbidden {
+		*violations = append(*violations, Violation{Pointer: pointer, Message: "isn't allowed"})
+		return
+	}
+	if !hasType(v, n.Type) {
+		*violations = append(*violations, Violation{Pointer: pointer, Message: fmt.Sprintf("must be of type %s, got %s", n.Type, typeOf(v))})
+		return
+	}
+	if len(n.Enum) > 0 && !slices.ContainsFunc(n.Enum, func(e any) bool { return reflect.DeepEqual(e, v) }) {
+		*violations = append(*violations, Violation{Pointer: pointer, Message: fmt.Sprintf("must be one of %s", enumString(n.Enum))})
+	}
+	if n.Const != nil && !reflect.DeepEqual(n.Const, v) {
+		*violations = append(*violations, Violation{Pointer: pointer, Message: fmt.Sprintf("must be %s", enumString([]any{n.Const}))})
+	}
+	add := func(format string, args ...any) {
+		*violations = append(*violations, Violation{Pointer: pointer, Message: fmt.Sprintf(format, args...)})
+	}
+	switch v := v.(type) {
+	case string:
+		length := utf8.RuneCountInString(v)
+		if n.MinLength != nil && length < *n.MinLength {
+			add("must be at least %d characters long", *n.MinLength)
+		}
+		if n.MaxLength != nil && length > *n.MaxLength {
+			add("must be at most %d characters long", *n.MaxLength)
+		}
+		if n.pattern != nil && !n.pattern.MatchString(v) {
+			add("must match the pattern %s", n.Pattern)
+		}
+	case float64:
+		if n.Minimum != nil && v < *n.Minimum {
+			add("must be at least %v", *n.Minimum)
+		}
+		if n.Maximum != nil && v > *n.Maximum {
+			add("must be at most %v", *n.Maximum)
+		}
+	case map[string]any:
+		for _, key := range n.Required {
+			if _, ok := v[key]; !ok {
+				*violations = append(*violations, Violation{Pointer: Pointer(pointer, key), Message: "is required"})
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if p, ok := n.Properties[key]; ok {
+				p.validate(Pointer(pointer, key), v[key], violations)
+			} else if n.AdditionalProperties != nil {
+				n.AdditionalProperties.validate(Pointer(pointer, key), v[key], violations)
+			}
+		}
+	case []any:
+		if n.MinItems != nil && len(v) < *n.MinItems {
+			add("must have at least %d items", *n.MinItems)
+		}
+		if n.MaxItems != nil && len(v) > *n.MaxItems {
+			add("must have at most %d items", *n.MaxItems)
+		}
+		if n.UniqueItems {
+			for i := range v {
+				if j := slices.IndexFunc(v[:i], func(e any) bool { return reflect.DeepEqual(e, v[i]) }); j >= 0 {
+					add("must have unique items, but %d and %d are the same", j, i)
+					break
+				}
+			}
+		}
+		for i, value := range v {
+			n.Items.validate(Pointer(pointer, fmt.Sprint(i)), value, violations)
+		}
+	}
+}
+
+func hasType(v any, t string) bool {
+	switch t {
+	case "":
+		return true
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == float64(int64(f))
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	}
+	return typeOf(v) == t
+}
+
+func typeOf(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func enumString(enum []any) string {
+	values := make([]string, len(enum))
+	for i, e := range enum {
+		b, _ := json.Marshal(e)
+		values[i] = string(b)
+	}
+	return strings.Join(values, ", ")
+}
@@ -0,0 +1,222 @@
+package schema
+
+import (
+	"encoding/json"
+	goerrors "errors"
+	"slices"
+	"testing"
+
+	"github.com/zachwalton/devoid/pkg/config"
+	"github.com/zachwalton/devoid/pkg/errors"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		doc    string
+		want   []string
+	}{
+		{
+			name:   "valid object",
+			schema: `{"type": "object", "required": ["name"], "properties": {"name": {"type": "string"}}}`,
+			doc:    `{"name": "app"}`,
+		},
+		{
+			name:   "missing required property",
+			schema: `{"type": "object", "required": ["name", "language"], "properties": {"name": {"type": "string"}}}`,
+			doc:    `{"name": "app"}`,
+			want:   []string{"/language: is required"},
+		},
+		{
+			name:   "wrong type",
+			schema: `{"type": "object", "properties": {"meta": {"type": "object", "properties": {"test": {"type": "string"}}}}}`,
+			doc:    `{"meta": {"test": 3}}`,
+			want:   []string{"/meta/test: must be of type string, got number"},
+		},
+		{
+			name:   "wrong root type",
+			schema: `{"type": "object"}`,
+			doc:    `[]`,
+			want:   []string{"/: must be of type object, got array"},
+		},
+		{
+			name:   "integer",
+			schema: `{"type": "integer"}`,
+			doc:    `1.5`,
+			want:   []string{"/: must be of type integer, got number"},
+		},
+		{
+			name:   "enum",
+			schema: `{"type": "string", "enum": ["create", "modify"]}`,
+			doc:    `"delete"`,
+			want:   []string{`/: must be one of "create", "modify"`},
+		},
+		{
+			name:   "enum of objects",
+			schema: `{"enum": [{"a": 1}, [1, 2]]}`,
+			doc:    `{"a": 1}`,
+		},
+		{
+			name:   "value not in enum of objects",
+			schema: `{"enum": [{"a": 1}, [1, 2]]}`,
+			doc:    `[2, 1]`,
+			want:   []string{`/: must be one of {"a":1}, [1,2]`},
+		},
+		{
+			name:   "const",
+			schema: `{"const": {"a": [1]}}`,
+			doc:    `{"a": [2]}`,
+			want:   []string{`/: must be {"a":[1]}`},
+		},
+		{
+			name:   "string length and pattern",
+			schema: `{"type": "string", "minLength": 3, "maxLength": 4, "pattern": "^[a-z]+$"}`,
+			doc:    `"A1"`,
+			want:   []string{"/: must be at least 3 characters long", "/: must match the pattern ^[a-z]+$"},
+		},
+		{
+			name:   "string length counts runes",
+			schema: `{"type": "string", "maxLength": 2}`,
+			doc:    `"éé"`,
+		},
+		{
+			name:   "number range",
+			schema: `{"type": "object", "properties": {"low": {"type": "number", "minimum": 1}, "high": {"type": "number", "maximum": 1}}}`,
+			doc:    `{"low": 0, "high": 2}`,
+			want:   []string{"/high: must be at most 1", "/low: must be at least 1"},
+		},
+		{
+			name:   "array items",
+			schema: `{"type": "array", "minItems": 1, "items": {"type": "string"}}`,
+			doc:    `["a", 1, "c"]`,
+			want:   []string{"/1: must be of type string, got number"},
+		},
+		{
+			name:   "array length",
+			schema: `{"type": "array", "minItems": 2, "maxItems": 3}`,
+			doc:    `[1]`,
+			want:   []string{"/: must have at least 2 items"},
+		},
+		{
+			name:   "unique items",
+			schema: `{"type": "array", "uniqueItems": true}`,
+			doc:    `[{"a": 1}, {"b": 2}, {"a": 1}]`,
+			want:   []string{"/: must have unique items, but 0 and 2 are the same"},
+		},
+		{
+			name:   "additional properties forbidden",
+			schema: `{"type": "object", "properties": {"a": {}}, "additionalProperties": false}`,
+			doc:    `{"a": 1, "b": 2}`,
+			want:   []string{"/b: isn't allowed"},
+		},
+		{
+			name:   "additional properties schema",
+			schema: `{"type": "object", "additionalProperties": {"type": "string"}}`,
+			doc:    `{"a": "x", "b": true}`,
+			want:   []string{"/b: must be of type string, got boolean"},
+		},
+		{
+			name:   "escaped pointer tokens",
+			schema: `{"type": "object", "additionalProperties": {"type": "string"}}`,
+			doc:    `{"a/b~c": null}`,
+			want:   []string{"/a~1b~0c: must be of type string, got null"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := Parse(tt.schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var doc any
+			if err := json.Unmarshal([]byte(tt.doc), &doc); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, v := range n.Validate(doc) {
+				got = append(got, v.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Validate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr bool
+	}{
+		{name: "initial", schema: SchemaInitial()},
+		{name: "ast", schema: SchemaAST()},
+		{name: "code", schema: SchemaCode()},
+		{name: "boolean schemas", schema: `{"properties": {"a": true, "b": false}}`},
+		{name: "invalid JSON", schema: `{`, wantErr: true},
+		{name: "unsupported draft", schema: `{"$schema": "https://json-schema.org/draft/2020-12/schema"}`, wantErr: true},
+		{name: "invalid pattern", schema: `{"properties": {"a": {"pattern": "("}}}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.schema); (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	n, err := Parse(`{"type": "object", "required": ["name"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		response string
+		wantErr  bool
+	}{
+		{name: "valid", response: `{"name": "app"}`},
+		{name: "invalid JSON", response: `{"name": `, wantErr: true},
+		{name: "violation", response: `{}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := n.Check(tt.response)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !goerrors.Is(err, errors.ErrRecoverable) {
+				t.Errorf("Check() error = %v, want %v", err, errors.ErrRecoverable)
+			}
+		})
+	}
+}
+
+func TestSchemaInitialWith(t *testing.T) {
+	s, err := SchemaInitialWith([]config.MetaField{{Name: "team", Options: []string{"platform", "none"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		pointer string
+		found   bool
+	}{
+		{"/meta/name", true},
+		{"/meta/custom/team", true},
+		{"/meta/custom/owner", false},
+		{"/ast/0/path", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pointer, func(t *testing.T) {
+			if got := n.Lookup(tt.pointer) != nil; got != tt.found {
+				t.Errorf("Lookup(%q) found = %v, want %v", tt.pointer, got, tt.found)
+			}
+		})
+	}
+}
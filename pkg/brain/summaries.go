@@ -0,0 +1,71 @@
+package brain
+
+import (
+	"embed"
+	"strings"
+	"text/template"
+
+	"github.com/zachwalton/devoid/pkg/brain/templates"
+	"github.com/zachwalton/devoid/pkg/secrets"
+	"github.com/zachwalton/devoid/pkg/validate"
+)
+
+// SummaryPartials defines the sections the stage summaries share, such as the
+// changes applied and the model's questions.
+const SummaryPartials = "partials"
+
+//go:embed summaries/*.tmpl
+var summaries embed.FS
+
+// Summaries are the Markdown summaries of each stage's result, rendered with
+// its *StagePayload. Registered stages name theirs, and they're overridden
+// from the summaries directory of the template directories, e.g.
+// summaries/ast.tmpl.
+var Summaries = templates.Set{
+	FS:    summaries,
+	Root:  "summaries",
+	Dir:   "summaries",
+	Base:  SummaryPartials,
+	Names: []string{SummaryPartials, "initial", "ast", "code"},
+	Funcs: template.FuncMap{
+		"join":  strings.Join,
+		"lines": func(s string) int { return strings.Count(strings.TrimSuffix(s, "\n"), "\n") + 1 },
+	},
+	Samples: []any{
+		&StagePayload{},
+		&StagePayload{
+			Meta:  MetaPayload{Name: "x", Blueprint: "x", TestCommand: "x", Existing: true, Custom: map[string]string{"x": "x"}},
+			AST:   []FileNode{{Path: "x", DependsOn: []string{"x"}}},
+			Files: []File{{Path: "x", Content: "x"}},
+			Commands: []Command{
+				{Command: "x", Action: "allow"},
+				{Command: "x", Action: "confirm"},
+				{Command: "x", Action: "block"},
+			},
+			Findings:    []secrets.Finding{{Path: "x", Line: 1}},
+			Diagnostics: []validate.Diagnostic{{Path: "x", Line: 1}},
+			StateMachine: StateMachinePayload{
+				ModifiedResult: true,
+				Questions: []Question{
+					{Question: "x", Type: QuestionChoice, Options: []string{"x"}, Default: "x"},
+					{Question: "x", Type: QuestionYesNo},
+				},
+			},
+		},
+	},
+	Variables: []templates.Variable{
+		{Name: ".Meta", Description: "The project metadata, e.g. .Meta.Name, .Meta.Language and .Meta.Custom, plus .Meta.CurrentStage, .Meta.ProjectPath and .Meta.Existing."},
+		{Name: ".AST", Description: "The planned files, each with .Path, .Purpose, .Action and .DependsOn."},
+		{Name: ".Files", Description: "The generated files, each with .Path and .Content."},
+		{Name: ".Commands", Description: "The bootstrap commands, each with .Command, .Action (allow, confirm or block) and .Reason."},
+		{Name: ".Findings", Description: "Likely secrets that were redacted, each with .Path, .Line, .Description and .Preview."},
+		{Name: ".Diagnostics", Description: "Static check problems the model didn't fix, each with .Path, .Line, .Tool and .Message."},
+		{Name: ".StateMachine", Description: "The model's .Description of its changes, .ModifiedResult, and its .Questions."},
+	},
+}
+
+// LoadSummaries returns the summary templates, with any overrides found in
+// dirs.
+func LoadSummaries(dirs ...string) (*templates.Library, error) {
+	return Summaries.Load(dirs...)
+}
@@ -1,8 +1,258 @@
 package templates
 
-type systemTemplate struct {
-	Prompt            string
-	ProjectDirectory  string
-	FieldDescriptions map[string]string
-	Schema            string
+import (
+	"bytes"
+	"embed"
+	goerrors "errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"text/template"
+
+	"github.com/zachwalton/devoid/pkg/config"
+	"github.com/zachwalton/devoid/pkg/errors"
+)
+
+// Extension is the file extension of templates on disk.
+const Extension = ".tmpl"
+
+// Embedded is the origin of templates that weren't overridden.
+const Embedded = "embedded"
+
+const (
+	Initial = "initial"
+	AST     = "ast"
+	Code    = "code"
+	// Clarify is the prompt for changes to a result the model already
+	// returned, e.g. a change request or answers to its questions.
+	Clarify = "clarify"
+	// System is shared by the initial, ast and code templates, which define
+	// "guidelines" and "fields" and then render it.
+	System = "system"
+)
+
+// Names are the prompt templates that can be overridden.
+var Names = []string{System, Initial, AST, Code, Clarify}
+
+//go:embed prompts/*.tmpl
+var defaults embed.FS
+
+type (
+	// Context carries the session details that system prompts are rendered
+	// with. Its fields are the variables available to templates, which are
+	// described in Variables.
+	Context struct {
+		ProjectDirectory string
+		// Existing is true when evolving a codebase that's already on disk
+		// rather than bootstrapping an empty directory.
+		Existing    bool
+		RepoSummary string
+		// Input is the JSON payload accepted in the previous stage, if any.
+		Input string
+		// Blueprints is the catalog of blueprints the initial stage can
+		// select.
+		Blueprints string
+		// Blueprint is the skeleton of the selected blueprint, if any.
+		Blueprint string
+		// Files is the current content of existing files the stage may
+		// modify.
+		Files string
+		// LastResponse is the result being changed, for the clarify
+		// template.
+		LastResponse string
+		// CustomFields are the custom metadata fields declared in the
+		// config.
+		CustomFields []config.MetaField
+	}
+
+	// Variable is a value templates can use.
+	Variable struct {
+		Name        string
+		Description string
+	}
+
+	// Set is a group of templates that are overridden from disk together
+	// and share a base template, e.g. the system prompts.
+	Set struct {
+		// FS holds the built-in templates in Root, as NAME.tmpl.
+		FS   embed.FS
+		Root string
+		// Dir is the subdirectory of the template directories that
+		// overrides are read from, or empty for the directories themselves.
+		Dir string
+		// Base defines what the other templates have in common. It's
+		// parsed along with each of them and never rendered on its own.
+		Base  string
+		Names []string
+		Funcs template.FuncMap
+		// Samples are rendered with every template when it's loaded.
+		Samples   []any
+		Variables []Variable
+	}
+
+	// Library is a set of templates, with any overrides loaded from disk.
+	Library struct {
+		set     Set
+		sources map[string]string
+		origins map[string]string
+		parsed  map[string]*template.Template
+	}
+)
+
+// Variables describes the fields of Context for people writing templates.
+var Variables = []Variable{
+	{".ProjectDirectory", "Path of the project directory."},
+	{".Existing", "True when evolving an existing codebase rather than bootstrapping an empty directory."},
+	{".RepoSummary", "Summary of the existing codebase's files and detected metadata, when evolving one."},
+	{".Input", "JSON result accepted in the previous stage. Empty in the initial stage."},
+	{".Blueprints", "Catalog of the blueprints the initial stage can choose from."},
+	{".Blueprint", "Skeleton of the chosen blueprint, if any, from the ast stage on."},
+	{".Files", "Current content of existing files the code stage may modify."},
+	{".LastResponse", "The result being changed. Only set for the clarify template."},
+	{".CustomFields", "Custom metadata fields from the config, each with .Name, .Description, .Options and .Default."},
+}
+
+// Dirs returns the directories templates are overridden from, highest
+// precedence first: the project's, unless projectDir is empty, then the
+// user's.
+func Dirs(projectDir string) []string {
+	var dirs []string
+	if projectDir != "" {
+		dirs = append(dirs, ProjectDir(projectDir))
+	}
+	if dir, err := UserDir(); err == nil {
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+// ProjectDir returns the project's template directory.
+func ProjectDir(projectDir string) string {
+	return filepath.Join(projectDir, ".devoid", "templates")
+}
+
+// UserDir returns the user's template directory.
+func UserDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "devoid", "templates"), nil
+}
+
+// Prompts are the system prompt templates.
+var Prompts = Set{
+	FS:    defaults,
+	Root:  "prompts",
+	Base:  System,
+	Names: Names,
+	Samples: []any{
+		&Context{},
+		&Context{ProjectDirectory: "x", Existing: true, RepoSummary: "x", Input: "x", Blueprints: "x", Blueprint: "x", Files: "x", LastResponse: "x", CustomFields: []config.MetaField{{Name: "x", Description: "x", Options: []string{"x"}, Default: "x"}}},
+	},
+	Variables: Variables,
+}
+
+// Default returns the embedded source of the named prompt template.
+func Default(name string) (string, error) {
+	return Prompts.Default(name)
+}
+
+// Load returns the prompt templates, with any overrides found in dirs.
+func Load(dirs ...string) (*Library, error) {
+	return Prompts.Load(dirs...)
+}
+
+// Qualified returns the name of a template in the set as it's given to the
+// templates command, which is its path relative to a template directory
+// without the extension, e.g. summaries/ast.
+func (s Set) Qualified(name string) string {
+	return path.Join(s.Dir, name)
+}
+
+// Default returns the embedded source of the named template.
+func (s Set) Default(name string) (string, error) {
+	b, err := s.FS.ReadFile(path.Join(s.Root, name+Extension))
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", errors.ErrUnknownTemplate, s.Qualified(name))
+	}
+	return string(b), nil
+}
+
+// Path returns where the named template overrides the built-in one in dir.
+func (s Set) Path(dir, name string) string {
+	return filepath.Join(dir, s.Dir, name+Extension)
+}
+
+// Load returns the embedded templates, with any found in dirs overriding them
+// by name, e.g. ast.tmpl. Earlier dirs take precedence. Every template is
+// parsed and rendered with the samples up front, so mistakes such as unknown
+// variables are reported before the session starts.
+func (s Set) Load(dirs ...string) (*Library, error) {
+	l := &Library{set: s, sources: map[string]string{}, origins: map[string]string{}, parsed: map[string]*template.Template{}}
+	for _, name := range s.Names {
+		src, err := s.Default(name)
+		if err != nil {
+			return nil, err
+		}
+		l.sources[name], l.origins[name] = src, Embedded
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if dirs[i] == "" {
+			continue
+		}
+		for _, name := range s.Names {
+			file := s.Path(dirs[i], name)
+			b, err := os.ReadFile(file)
+			if goerrors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("could not read template: %w", err)
+			}
+			l.sources[name], l.origins[name] = string(b), file
+		}
+	}
+
+	for _, name := range s.Names {
+		if name == s.Base {
+			continue
+		}
+		t, err := template.New(s.Base).Funcs(s.Funcs).Parse(l.sources[s.Base])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %s", errors.ErrInvalidTemplate, l.origins[s.Base], err)
+		}
+		if _, err := t.New(name).Parse(l.sources[name]); err != nil {
+			return nil, fmt.Errorf("%w: %s: %s", errors.ErrInvalidTemplate, l.origins[name], err)
+		}
+		for _, data := range s.Samples {
+			if err := t.ExecuteTemplate(io.Discard, name, data); err != nil {
+				return nil, fmt.Errorf("%w: %s: %s", errors.ErrInvalidTemplate, l.origins[name], err)
+			}
+		}
+		l.parsed[name] = t
+	}
+	return l, nil
+}
+
+// Origin returns where the named template was loaded from: a path, or
+// Embedded.
+func (l *Library) Origin(name string) string {
+	return l.origins[name]
+}
+
+// Render renders the named template with data, e.g. a *Context for prompts.
+func (l *Library) Render(name string, data any) (string, error) {
+	t, ok := l.parsed[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", errors.ErrUnknownTemplate, l.set.Qualified(name))
+	}
+	var b bytes.Buffer
+	if err := t.ExecuteTemplate(&b, name, data); err != nil {
+		return "", fmt.Errorf("%w: %s: %s", errors.ErrInvalidTemplate, l.origins[name], err)
+	}
+	return b.String(), nil
 }
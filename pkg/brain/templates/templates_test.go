@@ -0,0 +1,101 @@
+package templates
+
+import (
+	goerrors "errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/zachwalton/devoid/pkg/errors"
+)
+
+func TestLoad(t *testing.T) {
+	project, user := t.TempDir(), t.TempDir()
+	override(t, project, Clarify, "project clarify")
+	override(t, user, Clarify, "user clarify")
+	override(t, user, AST, `{{define "guidelines"}}user guidelines{{end}}{{define "fields"}}{{end}}{{template "system" .}}`)
+
+	l, err := Load(project, "", user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		origin string
+		want   string
+	}{
+		{name: Clarify, origin: filepath.Join(project, Clarify+Extension), want: "project clarify"},
+		{name: AST, origin: filepath.Join(user, AST+Extension), want: "user guidelines"},
+		{name: Code, origin: Embedded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := l.Origin(tt.name); got != tt.origin {
+				t.Errorf("Origin(%q) = %q, want %q", tt.name, got, tt.origin)
+			}
+			got, err := l.Render(tt.name, &Context{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("Render(%q) = %q, want it to contain %q", tt.name, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := l.Render(System, &Context{}); !goerrors.Is(err, errors.ErrUnknownTemplate) {
+		t.Errorf("Render(%q) error = %v, want %v", System, err, errors.ErrUnknownTemplate)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "syntax error", content: "{{if}}"},
+		{name: "unknown variable", content: "{{.Nope}}"},
+		{name: "undefined template", content: `{{template "nope" .}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			override(t, dir, Clarify, tt.content)
+			_, err := Load(dir)
+			if !goerrors.Is(err, errors.ErrInvalidTemplate) {
+				t.Fatalf("Load() error = %v, want %v", err, errors.ErrInvalidTemplate)
+			}
+			if !strings.Contains(err.Error(), filepath.Join(dir, Clarify+Extension)) {
+				t.Errorf("Load() error = %v, want it to name the file", err)
+			}
+		})
+	}
+}
+
+func override(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name+Extension), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDirs(t *testing.T) {
+	user, err := UserDir()
+	if err != nil {
+		t.Skip("no user config directory:", err)
+	}
+	tests := []struct {
+		project string
+		want    []string
+	}{
+		{project: "/src/app", want: []string{filepath.Join("/src/app", ".devoid", "templates"), user}},
+		{project: "", want: []string{user}},
+	}
+	for _, tt := range tests {
+		if got := Dirs(tt.project); !slices.Equal(got, tt.want) {
+			t.Errorf("Dirs(%q) = %q, want %q", tt.project, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zachwalton/devoid/pkg/brain"
+	"github.com/zachwalton/devoid/pkg/errors"
+	"github.com/zachwalton/devoid/pkg/workspace"
+)
+
+// StateDir is where devoid keeps its own state inside the project.
+const StateDir = ".devoid"
+
+// Checkpoint is the accepted result of a stage.
+type Checkpoint struct {
+	Stage     string              `json:"stage"`
+	Iteration int                 `json:"iteration"`
+	Model     string              `json:"model"`
+	Prompt    string              `json:"prompt"`
+	Time      time.Time           `json:"time"`
+	Payload   *brain.StagePayload `json:"payload"`
+	Writes    []workspace.Write   `json:"writes,omitempty"`
+}
+
+func Dir(projectPath string) string {
+	return filepath.Join(projectPath, StateDir, "checkpoints")
+}
+
+// file returns the path of the stage's checkpoint. Stage names can come from
+// the command line, so ones that could point outside the checkpoints directory
+// are rejected.
+func file(projectPath, stage string) (string, error) {
+	if strings.ContainsAny(stage, `/\`) || strings.Contains(stage, "..") {
+		return "", fmt.Errorf("%w: stage %q can't contain path separators or '..'", errors.ErrUnsafePath, stage)
+	}
+	return filepath.Join(Dir(projectPath), stage+".json"), nil
+}
+
+// Save writes the checkpoint for its stage, replacing any earlier one.
+func Save(projectPath string, c *Checkpoint) error {
+	p, err := file(projectPath, c.Stage)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(Dir(projectPath), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p, append(b, '\n'), 0o644)
+}
+
+func Load(projectPath, stage string) (*Checkpoint, error) {
+	p, err := file(projectPath, stage)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(p)
+	if goerrors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %q", errors.ErrNoCheckpoint, stage)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c Checkpoint
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("could not parse checkpoint for %q: %w", stage, err)
+	}
+	return &c, nil
+}
+
+// All returns every checkpoint in the project, oldest first.
+func All(projectPath string) ([]*Checkpoint, error) {
+	entries, err := os.ReadDir(Dir(projectPath))
+	if goerrors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var all []*Checkpoint
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		c, err := Load(projectPath, strings.TrimSuffix(e.Name(), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, c)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Time.Before(all[j].Time) })
+	return all, nil
+}
+
+// Latest returns the most recent checkpoint in the project.
+func Latest(projectPath string) (*Checkpoint, error) {
+	all, err := All(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(all) == 0 {
+		return nil, fmt.Errorf("%w: no checkpoints in %s", errors.ErrNoCheckpoint, projectPath)
+	}
+	return all[len(all)-1], nil
+}
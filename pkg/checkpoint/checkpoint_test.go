@@ -0,0 +1,63 @@
+package checkpoint
+
+import (
+	goerrors "errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zachwalton/devoid/pkg/brain"
+	"github.com/zachwalton/devoid/pkg/errors"
+)
+
+func TestLoad(t *testing.T) {
+	project := t.TempDir()
+	if err := Save(project, &Checkpoint{Stage: "ast", Iteration: 2, Payload: &brain.StagePayload{}}); err != nil {
+		t.Fatal(err)
+	}
+	// A JSON file outside the checkpoints directory that a stage name could
+	// otherwise reach.
+	if err := os.WriteFile(filepath.Join(project, StateDir, "outside.json"), []byte(`{"stage":"outside"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		stage   string
+		wantErr error
+	}{
+		{stage: "ast"},
+		{stage: "code", wantErr: errors.ErrNoCheckpoint},
+		{stage: "../outside", wantErr: errors.ErrUnsafePath},
+		{stage: "..", wantErr: errors.ErrUnsafePath},
+		{stage: "sub/ast", wantErr: errors.ErrUnsafePath},
+		{stage: `..\outside`, wantErr: errors.ErrUnsafePath},
+	}
+	for _, tt := range tests {
+		t.Run(tt.stage, func(t *testing.T) {
+			c, err := Load(project, tt.stage)
+			if tt.wantErr != nil {
+				if !goerrors.Is(err, tt.wantErr) {
+					t.Errorf("Load(%q) error = %v, want %v", tt.stage, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Stage != tt.stage || c.Iteration != 2 {
+				t.Errorf("Load(%q) = %+v", tt.stage, c)
+			}
+		})
+	}
+}
+
+func TestSaveUnsafeStage(t *testing.T) {
+	project := t.TempDir()
+	err := Save(project, &Checkpoint{Stage: "../../outside"})
+	if !goerrors.Is(err, errors.ErrUnsafePath) {
+		t.Errorf("Save() error = %v, want %v", err, errors.ErrUnsafePath)
+	}
+	if _, err := os.Stat(filepath.Join(project, "outside.json")); !goerrors.Is(err, os.ErrNotExist) {
+		t.Errorf("Save() wrote outside the checkpoints directory: %v", err)
+	}
+}
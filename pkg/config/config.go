@@ -1,17 +1,89 @@
 package config
 
+import "time"
+
 const (
 	ReasonerOllama Reasoner = "ollama"
+
+	PolicyAllow   PolicyAction = "allow"
+	PolicyConfirm PolicyAction = "confirm"
+	PolicyBlock   PolicyAction = "block"
+
+	SecretsRedact SecretsAction = "redact"
+	SecretsBlock  SecretsAction = "block"
 )
 
 type (
-	Reasoner string
+	Reasoner      string
+	PolicyAction  string
+	SecretsAction string
 
 	Config struct {
-		Prompt                      string `mapstructure:"prompt"`
-		ProjectPath                 string `mapstructure:"project-path"`
-		SkipInteractiveSafetyChecks bool   `mapstructure:"skip-interactive-safety-checks"`
-		LLM                         LLM    `mapstructure:"llm"`
+		Prompt                      string     `mapstructure:"prompt"`
+		ProjectPath                 string     `mapstructure:"project-path"`
+		Existing                    bool       `mapstructure:"existing"`
+		SkipInteractiveSafetyChecks bool       `mapstructure:"skip-interactive-safety-checks"`
+		BlueprintsDir               string     `mapstructure:"blueprints-dir"`
+		NoGit                       bool       `mapstructure:"no-git"`
+		NoHistory                   bool       `mapstructure:"no-history"`
+		LLM                         LLM        `mapstructure:"llm"`
+		Policy                      Policy     `mapstructure:"policy"`
+		Sandbox                     Sandbox    `mapstructure:"sandbox"`
+		Secrets                     Secrets    `mapstructure:"secrets"`
+		Validation                  Validation `mapstructure:"validation"`
+		Snippets                    []Snippet  `mapstructure:"snippets"`
+		UI                          UI         `mapstructure:"ui"`
+		Metadata                    Metadata   `mapstructure:"metadata"`
+	}
+
+	// Metadata extends the project metadata decided in the initial stage
+	// with fields of your own, e.g. the owning team.
+	Metadata struct {
+		Fields []MetaField `mapstructure:"fields"`
+	}
+
+	// MetaField is a custom metadata field. The model decides it like the
+	// built-in fields, guided by Description, and picks one of Options if
+	// there are any. Name must be lowercase letters, digits and
+	// underscores.
+	MetaField struct {
+		Name        string   `mapstructure:"name"`
+		Description string   `mapstructure:"description"`
+		Options     []string `mapstructure:"options"`
+		Default     string   `mapstructure:"default"`
+	}
+
+	// UI controls how the terminal UI looks. Colors are disabled when NoColor
+	// is set or the NO_COLOR environment variable isn't empty. The plain
+	// renderer prints line by line and reads answers from stdin; it's used
+	// automatically when stdout isn't a terminal.
+	UI struct {
+		NoColor bool  `mapstructure:"no-color"`
+		Plain   bool  `mapstructure:"plain"`
+		Theme   Theme `mapstructure:"theme"`
+	}
+
+	// Theme sets the colors of the terminal UI, as ANSI color numbers or hex
+	// codes. Empty colors use the defaults.
+	Theme struct {
+		// Accent colors borders and the background of title bars.
+		Accent   string `mapstructure:"accent"`
+		Title    string `mapstructure:"title"`
+		Selected string `mapstructure:"selected"`
+		Muted    string `mapstructure:"muted"`
+		Subtle   string `mapstructure:"subtle"`
+		Error    string `mapstructure:"error"`
+		// Markdown is a glamour style name, e.g. dark, light or dracula, or
+		// the path to a glamour JSON style. Defaults to dark or light to
+		// match the terminal's background.
+		Markdown string `mapstructure:"markdown"`
+	}
+
+	// Snippet is reusable text that can be inserted into change requests and
+	// answers.
+	Snippet struct {
+		Name string `mapstructure:"name"`
+		Text string `mapstructure:"text"`
 	}
 
 	LLM struct {
@@ -19,4 +91,72 @@ type (
 		Model       string   `mapstructure:"model"`
 		Temperature float64  `mapstructure:"temperature"`
 	}
+
+	// Policy controls which proposed commands may run. Rules are evaluated in
+	// order, before the built-in rules, and the first match wins.
+	Policy struct {
+		Default         PolicyAction `mapstructure:"default"`
+		DisableBuiltins bool         `mapstructure:"disable-builtins"`
+		Rules           []PolicyRule `mapstructure:"rules"`
+	}
+
+	// Sandbox controls how proposed commands are isolated. Limits of zero use
+	// the defaults; negative limits disable them. Env names environment
+	// variables to pass into the sandbox in addition to the built-in ones,
+	// e.g. PATH and HOME.
+	Sandbox struct {
+		AllowUnsandboxed bool          `mapstructure:"allow-unsandboxed"`
+		NoNetwork        bool          `mapstructure:"no-network"`
+		WritablePaths    []string      `mapstructure:"writable-paths"`
+		Env              []string      `mapstructure:"env"`
+		CPUSeconds       int           `mapstructure:"cpu-seconds"`
+		MemoryMB         int           `mapstructure:"memory-mb"`
+		Timeout          time.Duration `mapstructure:"timeout"`
+	}
+
+	// Secrets controls what happens to likely secrets in generated files and
+	// commands. They're redacted by default; blocking sends the result back to
+	// the model instead.
+	Secrets struct {
+		Action SecretsAction `mapstructure:"action"`
+		// Allow is a list of regular expressions matching values that
+		// aren't secrets, e.g. well-known test keys.
+		Allow          []string `mapstructure:"allow"`
+		DisableEntropy bool     `mapstructure:"disable-entropy"`
+	}
+
+	// Validation controls the static checks run on generated files. Go files
+	// are always parsed, gofmt'd and vetted; Checks add commands for other
+	// languages.
+	Validation struct {
+		Disabled bool `mapstructure:"disabled"`
+		// Retries is how many times the model is asked to fix problems
+		// before the files are shown as they are. Zero uses the default.
+		Retries int     `mapstructure:"retries"`
+		Checks  []Check `mapstructure:"checks"`
+	}
+
+	// Check is a formatter or linter command run on generated files with
+	// the given extensions. {files} in Command is replaced with their paths.
+	// Pattern is a regular expression with named groups path, line, column
+	// and message for parsing diagnostics from the output, and defaults to
+	// the path:line:column: message format.
+	Check struct {
+		Name       string   `mapstructure:"name"`
+		Extensions []string `mapstructure:"extensions"`
+		Command    string   `mapstructure:"command"`
+		Pattern    string   `mapstructure:"pattern"`
+	}
+
+	// PolicyRule matches a command by executable name, a regular expression
+	// over its arguments, a regular expression over the whole command, or any
+	// combination of them.
+	PolicyRule struct {
+		Name        string       `mapstructure:"name"`
+		Action      PolicyAction `mapstructure:"action"`
+		Executables []string     `mapstructure:"executables"`
+		Args        string       `mapstructure:"args"`
+		Pattern     string       `mapstructure:"pattern"`
+		Reason      string       `mapstructure:"reason"`
+	}
 )
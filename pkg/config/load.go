@@ -0,0 +1,56 @@
+package config
+
+import (
+	goerrors "errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/go-viper/mapstructure/v2"
+	"gopkg.in/yaml.v3"
+
+	"github.com/zachwalton/devoid/pkg/errors"
+)
+
+// DefaultPath returns the config file used when --config isn't provided.
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "devoid", "config.yaml")
+}
+
+// Load reads a YAML config file. A missing file is only an error when
+// required is true; otherwise an empty config is returned.
+func Load(path string, required bool) (*Config, error) {
+	cfg := &Config{}
+	if path == "" {
+		return cfg, nil
+	}
+	b, err := os.ReadFile(path)
+	if goerrors.Is(err, fs.ErrNotExist) && !required {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errors.ErrInvalidConfig, err)
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", errors.ErrInvalidConfig, path, err)
+	}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:      cfg,
+		ErrorUnused: true,
+		DecodeHook:  mapstructure.StringToTimeDurationHookFunc(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(raw); err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", errors.ErrInvalidConfig, path, err)
+	}
+	return cfg, nil
+}
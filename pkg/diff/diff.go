@@ -0,0 +1,207 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	context = 3
+	// maxCells caps the size of the LCS table. Larger inputs are diffed as a
+	// wholesale replacement.
+	maxCells = 16 * 1024 * 1024
+)
+
+type (
+	opKind int
+
+	op struct {
+		kind opKind
+		line string
+		// Line numbers in the old and new inputs, starting at 1.
+		oldLine, newLine int
+	}
+
+	// Stats counts the lines added and removed by a diff.
+	Stats struct {
+		Added   int
+		Removed int
+	}
+)
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// Unified returns a unified diff between oldText and newText, or an empty
+// string if they're identical.
+func Unified(oldName, newName, oldText, newText string) string {
+	ops := compute(splitLines(oldText), splitLines(newText))
+	hunks := group(ops)
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+	for _, h := range hunks {
+		oldStart, oldCount, newStart, newCount := span(h)
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		for _, o := range h {
+			switch o.kind {
+			case opEqual:
+				b.WriteString(" ")
+			case opDelete:
+				b.WriteString("-")
+			case opInsert:
+				b.WriteString("+")
+			}
+			b.WriteString(o.line)
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// Stat counts the lines added and removed between oldText and newText.
+func Stat(oldText, newText string) Stats {
+	var s Stats
+	for _, o := range compute(splitLines(oldText), splitLines(newText)) {
+		switch o.kind {
+		case opDelete:
+			s.Removed++
+		case opInsert:
+			s.Added++
+		}
+	}
+	return s
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// compute returns the edit script from a to b using the longest common
+// subsequence of lines.
+func compute(a, b []string) []op {
+	// Trim the common prefix and suffix, which keeps the table small for the
+	// usual case of a few edits in a large file.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []op
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, op{kind: opEqual, line: a[i], oldLine: i + 1, newLine: i + 1})
+	}
+
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	n, m := len(ma), len(mb)
+	if n*m > maxCells {
+		for i, l := range ma {
+			ops = append(ops, op{kind: opDelete, line: l, oldLine: prefix + i + 1})
+		}
+		for j, l := range mb {
+			ops = append(ops, op{kind: opInsert, line: l, newLine: prefix + j + 1})
+		}
+	} else {
+		lcs := make([][]int32, n+1)
+		for i := range lcs {
+			lcs[i] = make([]int32, m+1)
+		}
+		for i := n - 1; i >= 0; i-- {
+			for j := m - 1; j >= 0; j-- {
+				if ma[i] == mb[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else if lcs[i+1][j] >= lcs[i][j+1] {
+					lcs[i][j] = lcs[i+1][j]
+				} else {
+					lcs[i][j] = lcs[i][j+1]
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < n || j < m {
+			switch {
+			case i < n && j < m && ma[i] == mb[j]:
+				ops = append(ops, op{kind: opEqual, line: ma[i], oldLine: prefix + i + 1, newLine: prefix + j + 1})
+				i++
+				j++
+			case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+				ops = append(ops, op{kind: opDelete, line: ma[i], oldLine: prefix + i + 1})
+				i++
+			default:
+				ops = append(ops, op{kind: opInsert, line: mb[j], newLine: prefix + j + 1})
+				j++
+			}
+		}
+	}
+
+	for k := 0; k < suffix; k++ {
+		ai, bi := len(a)-suffix+k, len(b)-suffix+k
+		ops = append(ops, op{kind: opEqual, line: a[ai], oldLine: ai + 1, newLine: bi + 1})
+	}
+	return ops
+}
+
+// group splits the edit script into hunks with surrounding context.
+func group(ops []op) [][]op {
+	var (
+		hunks [][]op
+		start = -1
+		end   = -1
+	)
+	for i, o := range ops {
+		if o.kind == opEqual {
+			continue
+		}
+		lo := max(0, i-context)
+		if start >= 0 && lo <= end {
+			end = min(len(ops), i+context+1)
+			continue
+		}
+		if start >= 0 {
+			hunks = append(hunks, ops[start:end])
+		}
+		start, end = lo, min(len(ops), i+context+1)
+	}
+	if start >= 0 {
+		hunks = append(hunks, ops[start:end])
+	}
+	return hunks
+}
+
+func span(h []op) (oldStart, oldCount, newStart, newCount int) {
+	for _, o := range h {
+		if o.kind != opInsert {
+			if oldStart == 0 {
+				oldStart = o.oldLine
+			}
+			oldCount++
+		}
+		if o.kind != opDelete {
+			if newStart == 0 {
+				newStart = o.newLine
+			}
+			newCount++
+		}
+	}
+	// Empty ranges only happen when one side is empty, and refer to line 0.
+	if oldCount == 0 {
+		oldStart = 0
+	}
+	if newCount == 0 {
+		newStart = 0
+	}
+	return oldStart, oldCount, newStart, newCount
+}
@@ -0,0 +1,104 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{
+			name: "identical",
+			old:  "a\nb\n",
+			new:  "a\nb\n",
+		},
+		{
+			name: "new file",
+			new:  "a\nb\n",
+			want: "--- old\n+++ new\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name: "deleted file",
+			old:  "a\n",
+			want: "--- old\n+++ new\n@@ -1,1 +0,0 @@\n-a\n",
+		},
+		{
+			name: "changed line with context",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			new:  "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			want: "--- old\n+++ new\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "distant changes are separate hunks",
+			old:  "a\n1\n2\n3\n4\n5\n6\n7\nb\n",
+			new:  "A\n1\n2\n3\n4\n5\n6\n7\nB\n",
+			want: "--- old\n+++ new\n@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n@@ -6,4 +6,4 @@\n 5\n 6\n 7\n-b\n+B\n",
+		},
+		{
+			name: "nearby changes share a hunk",
+			old:  "a\n1\n2\nb\n",
+			new:  "A\n1\n2\nB\n",
+			want: "--- old\n+++ new\n@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n-b\n+B\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Unified("old", "new", tt.old, tt.new); got != tt.want {
+				t.Errorf("Unified() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStat(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     Stats
+	}{
+		{name: "identical", old: "a\n", new: "a\n"},
+		{name: "missing trailing newline", old: "a\nb", new: "a\nb\n"},
+		{name: "added", old: "a\n", new: "a\nb\nc\n", want: Stats{Added: 2}},
+		{name: "removed", old: "a\nb\n", new: "b\n", want: Stats{Removed: 1}},
+		{name: "moved line", old: "a\nb\nc\n", new: "b\nc\na\n", want: Stats{Added: 1, Removed: 1}},
+		{
+			name: "common lines are kept below the cap",
+			old:  lines("old", 100, "shared"),
+			new:  lines("new", 100, "shared"),
+			want: Stats{Added: 100, Removed: 100},
+		},
+		{
+			// 4097 lines on each side is just over maxCells, so the shared
+			// line is replaced rather than matched.
+			name: "inputs above the cap are replaced wholesale",
+			old:  lines("old", 4096, "shared"),
+			new:  lines("new", 4096, "shared"),
+			want: Stats{Added: 4097, Removed: 4097},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Stat(tt.old, tt.new); got != tt.want {
+				t.Errorf("Stat() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// lines returns n distinct lines with shared in the middle, so it's neither a
+// common prefix nor a common suffix.
+func lines(prefix string, n int, shared string) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		if i == n/2 {
+			b.WriteString(shared + "\n")
+		}
+		fmt.Fprintf(&b, "%s %d\n", prefix, i)
+	}
+	return b.String()
+}
@@ -0,0 +1,151 @@
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+
+	"github.com/zachwalton/devoid/pkg/brain/schema"
+)
+
+// ChangeKind is how a field differs between two documents.
+type ChangeKind string
+
+const (
+	Added    ChangeKind = "added"
+	Removed  ChangeKind = "removed"
+	Modified ChangeKind = "modified"
+)
+
+// keyField identifies the elements of arrays of objects, such as planned and
+// generated files, so they're compared by identity rather than position.
+const keyField = "path"
+
+// Change is a field that differs between two JSON documents.
+type Change struct {
+	Kind ChangeKind
+	// Pointer is the field's JSON pointer in the document it's in, preferring
+	// the newer one.
+	Pointer string
+	// Label is a readable name for the field, e.g. ast[main.go].purpose,
+	// naming array elements by their path where they have one.
+	Label  string
+	Before any
+	After  any
+}
+
+// Fields compares two documents decoded by encoding/json, returning the
+// fields that were added, removed or modified. Object keys are compared in
+// sorted order, and arrays of objects that each have a unique path are
+// matched by path, so inserting a file doesn't show every later one as
+// changed.
+func Fields(before, after any) []Change {
+	var changes []Change
+	compareFields("", "", before, after, &changes)
+	return changes
+}
+
+func compareFields(pointer, label string, before, after any, changes *[]Change) {
+	switch b := before.(type) {
+	case map[string]any:
+		a, ok := after.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := map[string]bool{}
+		for k := range b {
+			keys[k] = true
+		}
+		for k := range a {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			p, l := schema.Pointer(pointer, k), k
+			if label != "" {
+				l = label + "." + k
+			}
+			bv, inBefore := b[k]
+			av, inAfter := a[k]
+			switch {
+			case !inBefore:
+				*changes = append(*changes, Change{Kind: Added, Pointer: p, Label: l, After: av})
+			case !inAfter:
+				*changes = append(*changes, Change{Kind: Removed, Pointer: p, Label: l, Before: bv})
+			default:
+				compareFields(p, l, bv, av, changes)
+			}
+		}
+		return
+	case []any:
+		a, ok := after.([]any)
+		if !ok {
+			break
+		}
+		if keys(b) != nil && keys(a) != nil {
+			compareKeyed(pointer, label, b, a, changes)
+			return
+		}
+		for i := range max(len(a), len(b)) {
+			p, l := schema.Pointer(pointer, fmt.Sprint(i)), fmt.Sprintf("%s[%d]", label, i)
+			switch {
+			case i >= len(b):
+				*changes = append(*changes, Change{Kind: Added, Pointer: p, Label: l, After: a[i]})
+			case i >= len(a):
+				*changes = append(*changes, Change{Kind: Removed, Pointer: p, Label: l, Before: b[i]})
+			default:
+				compareFields(p, l, b[i], a[i], changes)
+			}
+		}
+		return
+	}
+	if !reflect.DeepEqual(before, after) {
+		*changes = append(*changes, Change{Kind: Modified, Pointer: pointer, Label: label, Before: before, After: after})
+	}
+}
+
+// compareKeyed compares arrays whose elements are identified by keyField.
+// Elements are reported in their order before, followed by any added.
+func compareKeyed(pointer, label string, before, after []any, changes *[]Change) {
+	beforeKeys, afterKeys := keys(before), keys(after)
+	for i, k := range beforeKeys {
+		l := fmt.Sprintf("%s[%s]", label, k)
+		j := slices.Index(afterKeys, k)
+		if j < 0 {
+			*changes = append(*changes, Change{Kind: Removed, Pointer: schema.Pointer(pointer, fmt.Sprint(i)), Label: l, Before: before[i]})
+			continue
+		}
+		compareFields(schema.Pointer(pointer, fmt.Sprint(j)), l, before[i], after[j], changes)
+	}
+	for j, k := range afterKeys {
+		if !slices.Contains(beforeKeys, k) {
+			*changes = append(*changes, Change{Kind: Added, Pointer: schema.Pointer(pointer, fmt.Sprint(j)), Label: fmt.Sprintf("%s[%s]", label, k), After: after[j]})
+		}
+	}
+}
+
+// keys returns the keyField of every element of s, or nil if s is empty or
+// any element doesn't have a unique one.
+func keys(s []any) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	var ks []string
+	for _, v := range s {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		k, ok := obj[keyField].(string)
+		if !ok || slices.Contains(ks, k) {
+			return nil
+		}
+		ks = append(ks, k)
+	}
+	return ks
+}
@@ -6,9 +6,51 @@ import (
 
 var (
 	// Main
-	ErrNoPrompt    = errors.New("prompt not provided")
-	ErrRecoverable = errors.New("recoverable error")
+	ErrNoPrompt      = errors.New("prompt not provided")
+	ErrNoProjectPath = errors.New(`required flag "project-path" not set`)
+	ErrRecoverable   = errors.New("recoverable error")
+	ErrInvalidConfig = errors.New("invalid config")
 
 	// LLM
 	ErrUnknownType = errors.New("unknown model type")
+
+	// Blueprints
+	ErrInvalidBlueprint = errors.New("invalid blueprint")
+	ErrUnknownBlueprint = errors.New("unknown blueprint")
+	ErrInvalidName      = errors.New("invalid project name")
+
+	// Commands
+	ErrCommandFailed  = errors.New("command failed")
+	ErrCommandBlocked = errors.New("command blocked by policy")
+	ErrInvalidPolicy  = errors.New("invalid policy")
+
+	// Workspace
+	ErrUnsafePath = errors.New("unsafe path")
+	ErrOverwrite  = errors.New("refusing to overwrite file")
+
+	// Checkpoints
+	ErrNoCheckpoint  = errors.New("checkpoint not found")
+	ErrGit           = errors.New("git error")
+	ErrStageNotFound = errors.New("stage not found")
+	ErrDirtyWorktree = errors.New("project has uncommitted changes")
+
+	// Audit
+	ErrNoSession = errors.New("session not found")
+
+	// Graph
+	ErrNoGraph       = errors.New("no dependency graph")
+	ErrUnknownFormat = errors.New("unknown format")
+
+	// Reports
+	ErrNothingToReport = errors.New("nothing to report")
+
+	// Templates
+	ErrInvalidTemplate = errors.New("invalid template")
+	ErrUnknownTemplate = errors.New("unknown template")
+
+	// Validation
+	ErrValidationFailed = errors.New("validation failed")
+
+	// Sandbox
+	ErrSandboxUnavailable = errors.New("sandbox unavailable")
 )
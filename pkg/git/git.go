@@ -0,0 +1,279 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/zachwalton/devoid/pkg/errors"
+)
+
+const (
+	trailerStage     = "Devoid-Stage"
+	trailerIteration = "Devoid-Iteration"
+	trailerModel     = "Devoid-Model"
+	trailerPrompt    = "Devoid-Prompt"
+	trailerProject   = "Devoid-Project"
+
+	maxPromptSummary = 120
+)
+
+type (
+	// Repo is the git repository a project lives in. The project may be the
+	// root of the repository or a directory inside it, in which case every
+	// operation is scoped to the project directory.
+	Repo struct {
+		dir      string
+		toplevel bool
+		// project is the project's path relative to the root of the
+		// repository, or "." if it's the root.
+		project string
+	}
+
+	// Message describes a stage commit.
+	Message struct {
+		Stage       string
+		Description string
+		Iteration   int
+		Model       string
+		Prompt      string
+	}
+)
+
+// Open returns the repository containing dir, initializing one in dir if it
+// isn't inside a repository yet.
+func Open(dir string) (*Repo, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("%w: git wasn't found", errors.ErrGit)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	r, err := newRepo(dir)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.git("rev-parse", "--git-dir"); err != nil {
+		if _, err := r.git("init", "--quiet"); err != nil {
+			return nil, err
+		}
+	}
+	return r, r.findToplevel()
+}
+
+// OpenExisting returns the repository containing dir without creating
+// anything, for commands that only work with what's already there.
+func OpenExisting(dir string) (*Repo, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("%w: git wasn't found", errors.ErrGit)
+	}
+	r, err := newRepo(dir)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.git("rev-parse", "--git-dir"); err != nil {
+		return nil, fmt.Errorf("%w: %s isn't in a git repository", errors.ErrGit, dir)
+	}
+	return r, r.findToplevel()
+}
+
+func newRepo(dir string) (*Repo, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if abs, err = filepath.EvalSymlinks(abs); err != nil {
+		return nil, err
+	}
+	return &Repo{dir: abs}, nil
+}
+
+// findToplevel records where the project is in the repository.
+func (r *Repo) findToplevel() error {
+	prefix, err := r.git("rev-parse", "--show-prefix")
+	if err != nil {
+		return err
+	}
+	r.project = strings.TrimSuffix(strings.TrimSpace(prefix), "/")
+	if r.project == "" {
+		r.project = "."
+	}
+	r.toplevel = r.project == "."
+	return nil
+}
+
+// HasCommits returns true if the repository has at least one commit.
+func (r *Repo) HasCommits() bool {
+	_, err := r.git("rev-parse", "--verify", "--quiet", "HEAD")
+	return err == nil
+}
+
+// Dirty returns true if the project has uncommitted or untracked changes.
+func (r *Repo) Dirty() (bool, error) {
+	out, err := r.git("status", "--porcelain", "--", ".")
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(out) != "", nil
+}
+
+// Commit stages every change in the project and commits it, returning the
+// commit hash. Changes staged outside the project aren't committed. Commits
+// are made even if nothing changed, so every stage has one to roll back to.
+func (r *Repo) Commit(subject, body string) (string, error) {
+	if _, err := r.git("add", "--all", "--", "."); err != nil {
+		return "", err
+	}
+	msg := subject
+	if body != "" {
+		msg += "\n\n" + body
+	}
+	args := []string{"commit", "--quiet", "--allow-empty", "--no-verify", "-m", msg}
+	if !r.toplevel {
+		// A plain commit takes the whole index, so if anything outside the
+		// project is staged, only the project's changes are committed and the
+		// rest stay staged. Git rejects the pathspec when nothing in the
+		// project is tracked, so it's only given when needed.
+		outside, err := r.git("diff", "--cached", "--name-only", "--", ":/", ":(exclude).")
+		if err != nil {
+			return "", err
+		}
+		if outside = strings.TrimSpace(outside); outside != "" {
+			tracked, err := r.git("ls-files", "--", ".")
+			if err != nil {
+				return "", err
+			}
+			if strings.TrimSpace(tracked) == "" {
+				return "", fmt.Errorf("%w: changes outside the project are staged, commit or unstage them first: %s", errors.ErrGit, strings.ReplaceAll(outside, "\n", ", "))
+			}
+			args = append(args, "--", ".")
+		}
+	}
+	if _, err := r.git(args...); err != nil {
+		return "", err
+	}
+	hash, err := r.git("rev-parse", "HEAD")
+	return strings.TrimSpace(hash), err
+}
+
+// CommitStage commits the project with a structured message for the stage.
+func (r *Repo) CommitStage(m Message) (string, error) {
+	return r.Commit(m.subject(), m.body(r.project))
+}
+
+// FindStage returns the most recent commit for stage, matched on its
+// Devoid-Stage and Devoid-Project trailers. The log isn't limited to the
+// project's path, since that would skip stages committed without changes.
+func (r *Repo) FindStage(stage string) (string, error) {
+	out, err := r.git("log", "--format=%H%x00%(trailers:key="+trailerStage+",valueonly)%x00%(trailers:key="+trailerProject+",valueonly)%x00")
+	if err != nil && r.HasCommits() {
+		return "", err
+	}
+	fields := strings.Split(out, "\x00")
+	for i := 0; i+2 < len(fields); i += 3 {
+		if strings.TrimSpace(fields[i+1]) == stage && strings.TrimSpace(fields[i+2]) == r.project {
+			return strings.TrimSpace(fields[i]), nil
+		}
+	}
+	return "", fmt.Errorf("%w: no commit found for stage %q", errors.ErrStageNotFound, stage)
+}
+
+// Reset resets the project's working tree to commit, discarding any changes
+// made since. When the project is the repository root, HEAD moves to commit
+// too; otherwise only the project directory is restored.
+func (r *Repo) Reset(commit string) error {
+	if r.toplevel {
+		if _, err := r.git("reset", "--quiet", "--hard", commit); err != nil {
+			return err
+		}
+		_, err := r.git("clean", "--quiet", "-fd")
+		return err
+	}
+	if _, err := r.git("restore", "--source", commit, "--staged", "--worktree", "--", "."); err != nil {
+		return err
+	}
+	_, err := r.git("clean", "--quiet", "-fd", "--", ".")
+	return err
+}
+
+// Exclude adds a pattern to the repository's local exclude file, which
+// ignores it without touching the project's .gitignore.
+func (r *Repo) Exclude(pattern string) error {
+	gitDir, err := r.git("rev-parse", "--git-path", "info/exclude")
+	if err != nil {
+		return err
+	}
+	path := strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(r.dir, path)
+	}
+	existing, _ := os.ReadFile(path)
+	for _, line := range strings.Split(string(existing), "\n") {
+		if strings.TrimSpace(line) == pattern {
+			return nil
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if len(existing) > 0 && !bytes.HasSuffix(existing, []byte("\n")) {
+		pattern = "\n" + pattern
+	}
+	_, err = f.WriteString(pattern + "\n")
+	return err
+}
+
+func (r *Repo) git(args ...string) (string, error) {
+	c := exec.Command("git", args...)
+	c.Dir = r.dir
+	// Fall back to a devoid identity when the user hasn't configured one, so
+	// commits don't fail on fresh machines.
+	c.Env = os.Environ()
+	if name, _ := exec.Command("git", "-C", r.dir, "config", "user.name").Output(); len(bytes.TrimSpace(name)) == 0 {
+		c.Env = append(c.Env, "GIT_AUTHOR_NAME=devoid", "GIT_COMMITTER_NAME=devoid")
+	}
+	if email, _ := exec.Command("git", "-C", r.dir, "config", "user.email").Output(); len(bytes.TrimSpace(email)) == 0 {
+		c.Env = append(c.Env, "GIT_AUTHOR_EMAIL=devoid@localhost", "GIT_COMMITTER_EMAIL=devoid@localhost")
+	}
+	var stdout, stderr bytes.Buffer
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	if err := c.Run(); err != nil {
+		return "", fmt.Errorf("%w: git %s: %s", errors.ErrGit, args[0], strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+func (m Message) subject() string {
+	return fmt.Sprintf("devoid: %s stage (iteration %d)", m.Stage, m.Iteration)
+}
+
+func (m Message) body(project string) string {
+	var b strings.Builder
+	if m.Description != "" {
+		b.WriteString(m.Description + "\n\n")
+	}
+	fmt.Fprintf(&b, "%s: %s\n", trailerStage, m.Stage)
+	fmt.Fprintf(&b, "%s: %d\n", trailerIteration, m.Iteration)
+	fmt.Fprintf(&b, "%s: %s\n", trailerModel, m.Model)
+	fmt.Fprintf(&b, "%s: %s\n", trailerPrompt, summarize(m.Prompt))
+	fmt.Fprintf(&b, "%s: %s\n", trailerProject, project)
+	return b.String()
+}
+
+// summarize collapses the prompt onto one line short enough for a trailer.
+func summarize(prompt string) string {
+	s := strings.Join(strings.Fields(prompt), " ")
+	if len(s) > maxPromptSummary {
+		s = s[:maxPromptSummary-3] + "..."
+	}
+	return s
+}
@@ -0,0 +1,256 @@
+package git
+
+import (
+	goerrors "errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zachwalton/devoid/pkg/errors"
+)
+
+func TestOpenExisting(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	if _, err := OpenExisting(missing); err == nil {
+		t.Error("OpenExisting() succeeded for a missing directory")
+	}
+	if _, err := os.Stat(missing); !goerrors.Is(err, os.ErrNotExist) {
+		t.Errorf("OpenExisting() created %s: %v", missing, err)
+	}
+
+	dir := t.TempDir()
+	if _, err := OpenExisting(dir); !goerrors.Is(err, errors.ErrGit) {
+		t.Errorf("OpenExisting() error = %v, want %v", err, errors.ErrGit)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); !goerrors.Is(err, os.ErrNotExist) {
+		t.Errorf("OpenExisting() initialized a repository: %v", err)
+	}
+
+	open(t, dir)
+	r, err := OpenExisting(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.toplevel {
+		t.Error("toplevel = false for the repository root")
+	}
+}
+
+func TestCommitStage(t *testing.T) {
+	r := open(t, t.TempDir())
+	if r.HasCommits() {
+		t.Fatal("HasCommits() = true in a new repository")
+	}
+	if _, err := r.FindStage("initial"); !goerrors.Is(err, errors.ErrStageNotFound) {
+		t.Errorf("FindStage() error = %v, want %v", err, errors.ErrStageNotFound)
+	}
+
+	write(t, r.dir, "main.go", "package main\n")
+	dirty, err := r.Dirty()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dirty {
+		t.Error("Dirty() = false with an untracked file")
+	}
+	initial, err := r.CommitStage(Message{Stage: "initial", Iteration: 1, Model: "m", Prompt: "a\nprompt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Stages are committed even when nothing changed.
+	ast, err := r.CommitStage(Message{Stage: "ast", Iteration: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if initial == ast {
+		t.Error("CommitStage() didn't commit without changes")
+	}
+	if dirty, _ := r.Dirty(); dirty {
+		t.Error("Dirty() = true after committing")
+	}
+
+	tests := []struct {
+		stage string
+		want  string
+	}{
+		{"initial", initial},
+		{"ast", ast},
+	}
+	for _, tt := range tests {
+		got, err := r.FindStage(tt.stage)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("FindStage(%q) = %s, want %s", tt.stage, got, tt.want)
+		}
+	}
+
+	msg := run(t, r.dir, "log", "-1", "--format=%B", initial)
+	for _, want := range []string{"devoid: initial stage (iteration 1)", "Devoid-Stage: initial", "Devoid-Model: m", "Devoid-Prompt: a prompt", "Devoid-Project: ."} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("commit message %q doesn't contain %q", msg, want)
+		}
+	}
+}
+
+func TestReset(t *testing.T) {
+	r := open(t, t.TempDir())
+	write(t, r.dir, "main.go", "package main\n")
+	commit, err := r.CommitStage(Message{Stage: "initial", Iteration: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, r.dir, "main.go", "package changed\n")
+	write(t, r.dir, "new.go", "package main\n")
+
+	if err := r.Reset(commit); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(r.dir, "main.go")); string(b) != "package main\n" {
+		t.Errorf("main.go = %q after Reset()", b)
+	}
+	if _, err := os.Stat(filepath.Join(r.dir, "new.go")); !goerrors.Is(err, os.ErrNotExist) {
+		t.Errorf("new.go still exists after Reset(): %v", err)
+	}
+}
+
+func TestResetSubdirectory(t *testing.T) {
+	root := t.TempDir()
+	open(t, root)
+	write(t, root, "README.md", "outside\n")
+	r := open(t, filepath.Join(root, "app"))
+	if r.toplevel {
+		t.Fatal("toplevel = true for a subdirectory")
+	}
+	write(t, r.dir, "main.go", "package main\n")
+	commit, err := r.CommitStage(Message{Stage: "initial", Iteration: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, r.dir, "main.go", "package changed\n")
+	write(t, root, "README.md", "changed outside\n")
+
+	if err := r.Reset(commit); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(r.dir, "main.go")); string(b) != "package main\n" {
+		t.Errorf("main.go = %q after Reset()", b)
+	}
+	if b, _ := os.ReadFile(filepath.Join(root, "README.md")); string(b) != "changed outside\n" {
+		t.Errorf("Reset() changed a file outside the project: %q", b)
+	}
+}
+
+func TestCommitStagedOutside(t *testing.T) {
+	root := t.TempDir()
+	open(t, root)
+	r := open(t, filepath.Join(root, "app"))
+	write(t, root, "README.md", "outside\n")
+	run(t, root, "add", "README.md")
+
+	// The project is empty, so the commit can't be limited to it.
+	if _, err := r.CommitStage(Message{Stage: "initial", Iteration: 1}); !goerrors.Is(err, errors.ErrGit) {
+		t.Fatalf("CommitStage() error = %v, want %v", err, errors.ErrGit)
+	}
+
+	write(t, r.dir, "util.go", "package main\n")
+	commit, err := r.CommitStage(Message{Stage: "ast", Iteration: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := run(t, root, "show", "--name-only", "--format=", commit); got != "app/util.go\n" {
+		t.Errorf("committed files = %q, want %q", got, "app/util.go\n")
+	}
+	if got := run(t, root, "diff", "--cached", "--name-only"); got != "README.md\n" {
+		t.Errorf("staged files = %q, want %q", got, "README.md\n")
+	}
+}
+
+func TestFindStageSubdirectory(t *testing.T) {
+	root := t.TempDir()
+	open(t, root)
+	app, lib := open(t, filepath.Join(root, "app")), open(t, filepath.Join(root, "lib"))
+	write(t, app.dir, "main.go", "package main\n")
+	initial, err := app.CommitStage(Message{Stage: "initial", Iteration: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The ast stage doesn't change the app, and lib's commit is for another
+	// project.
+	ast, err := app.CommitStage(Message{Stage: "ast", Iteration: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, lib.dir, "lib.go", "package lib\n")
+	if _, err := lib.CommitStage(Message{Stage: "ast", Iteration: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	for stage, want := range map[string]string{"initial": initial, "ast": ast} {
+		got, err := app.FindStage(stage)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("FindStage(%q) = %s, want %s", stage, got, want)
+		}
+	}
+	if _, err := lib.FindStage("initial"); !goerrors.Is(err, errors.ErrStageNotFound) {
+		t.Errorf("FindStage() error = %v, want %v", err, errors.ErrStageNotFound)
+	}
+}
+
+func TestExclude(t *testing.T) {
+	r := open(t, t.TempDir())
+	for i := 0; i < 2; i++ {
+		if err := r.Exclude(".devoid/"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b, err := os.ReadFile(filepath.Join(r.dir, ".git", "info", "exclude"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(b), ".devoid/\n"); n != 1 {
+		t.Errorf("exclude file has %d entries for the pattern, want 1:\n%s", n, b)
+	}
+	write(t, r.dir, ".devoid/sessions/x.jsonl", "{}\n")
+	if dirty, _ := r.Dirty(); dirty {
+		t.Error("Dirty() = true with only excluded files")
+	}
+}
+
+func open(t *testing.T, dir string) *Repo {
+	t.Helper()
+	r, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func write(t *testing.T, dir, name, content string) {
+	t.Helper()
+	p := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func run(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	c := exec.Command("git", args...)
+	c.Dir = dir
+	c.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@localhost", "GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@localhost")
+	out, err := c.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %s", strings.Join(args, " "), out)
+	}
+	return string(out)
+}
@@ -0,0 +1,164 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/zachwalton/devoid/pkg/brain"
+	"github.com/zachwalton/devoid/pkg/errors"
+)
+
+// Version is the version of the JSON format, which is bumped whenever a
+// change would break existing readers.
+const Version = 1
+
+const (
+	FormatDOT     = "dot"
+	FormatMermaid = "mermaid"
+	FormatJSON    = "json"
+)
+
+// Formats lists the supported export formats.
+var Formats = []string{FormatDOT, FormatMermaid, FormatJSON}
+
+type (
+	// Graph is the dependency graph of a planned codebase. Nodes and edges
+	// are sorted so exports are stable across runs.
+	Graph struct {
+		Version int    `json:"version"`
+		Name    string `json:"name"`
+		Nodes   []Node `json:"nodes"`
+		Edges   []Edge `json:"edges"`
+	}
+
+	Node struct {
+		Path    string `json:"path"`
+		Purpose string `json:"purpose"`
+		Action  string `json:"action"`
+	}
+
+	// Edge points from a file to a file it depends on.
+	Edge struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	}
+)
+
+// New builds the graph for the files in ast. Dependencies on files that
+// aren't in ast are left out.
+func New(name string, ast []brain.FileNode) *Graph {
+	g := &Graph{Version: Version, Name: name, Nodes: []Node{}, Edges: []Edge{}}
+	known := map[string]bool{}
+	for _, n := range ast {
+		p := path.Clean(n.Path)
+		if known[p] {
+			continue
+		}
+		known[p] = true
+		g.Nodes = append(g.Nodes, Node{Path: p, Purpose: n.Purpose, Action: n.Action})
+	}
+	seen := map[Edge]bool{}
+	for _, n := range ast {
+		for _, dep := range n.DependsOn {
+			e := Edge{From: path.Clean(n.Path), To: path.Clean(dep)}
+			if !known[e.To] || e.From == e.To || seen[e] {
+				continue
+			}
+			seen[e] = true
+			g.Edges = append(g.Edges, e)
+		}
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].Path < g.Nodes[j].Path })
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+	return g
+}
+
+// Export renders the graph in one of Formats.
+func (g *Graph) Export(format string) (string, error) {
+	switch format {
+	case FormatDOT:
+		return g.DOT(), nil
+	case FormatMermaid:
+		return g.Mermaid(), nil
+	case FormatJSON:
+		b, err := json.MarshalIndent(g, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(b) + "\n", nil
+	}
+	return "", fmt.Errorf("%w: %q, must be one of %s", errors.ErrUnknownFormat, format, strings.Join(Formats, ", "))
+}
+
+// DOT renders the graph for Graphviz, e.g. `dot -Tsvg`.
+func (g *Graph) DOT() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(g.name()))
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, fontname=\"monospace\"];\n")
+	for _, n := range g.Nodes {
+		attrs := []string{"tooltip=" + dotQuote(n.Purpose)}
+		if n.Action == brain.ActionModify {
+			attrs = append(attrs, "style=dashed")
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(n.Path), strings.Join(attrs, ", "))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(e.From), dotQuote(e.To))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid renders the graph as a Mermaid flowchart, which GitHub and many
+// other Markdown renderers draw inside a mermaid code block.
+func (g *Graph) Mermaid() string {
+	ids := map[string]string{}
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for i, n := range g.Nodes {
+		// Paths aren't valid Mermaid IDs, so nodes get short IDs and the path
+		// as their label.
+		ids[n.Path] = fmt.Sprintf("n%d", i)
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", ids[n.Path], mermaidEscape(n.Path))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "  %s --> %s\n", ids[e.From], ids[e.To])
+	}
+	var modified []string
+	for _, n := range g.Nodes {
+		if n.Action == brain.ActionModify {
+			modified = append(modified, ids[n.Path])
+		}
+	}
+	if len(modified) > 0 {
+		b.WriteString("  classDef modify stroke-dasharray: 5 5\n")
+		fmt.Fprintf(&b, "  class %s modify\n", strings.Join(modified, ","))
+	}
+	return b.String()
+}
+
+func (g *Graph) name() string {
+	if g.Name == "" {
+		return "devoid"
+	}
+	return g.Name
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// mermaidEscape replaces the characters that end a quoted Mermaid label with
+// entity codes.
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "\n", " ").Replace(s)
+}
@@ -0,0 +1,132 @@
+package graph
+
+import (
+	"encoding/json"
+	goerrors "errors"
+	"slices"
+	"testing"
+
+	"github.com/zachwalton/devoid/pkg/brain"
+	"github.com/zachwalton/devoid/pkg/errors"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name  string
+		ast   []brain.FileNode
+		nodes []string
+		edges []Edge
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "sorted nodes and edges",
+			ast: []brain.FileNode{
+				{Path: "main.go", DependsOn: []string{"pkg/server.go", "pkg/config.go"}},
+				{Path: "pkg/server.go", DependsOn: []string{"pkg/config.go"}},
+				{Path: "pkg/config.go"},
+			},
+			nodes: []string{"main.go", "pkg/config.go", "pkg/server.go"},
+			edges: []Edge{{"main.go", "pkg/config.go"}, {"main.go", "pkg/server.go"}, {"pkg/server.go", "pkg/config.go"}},
+		},
+		{
+			name: "paths are cleaned",
+			ast: []brain.FileNode{
+				{Path: "./cmd/main.go", DependsOn: []string{"pkg//server.go"}},
+				{Path: "pkg/server.go"},
+			},
+			nodes: []string{"cmd/main.go", "pkg/server.go"},
+			edges: []Edge{{"cmd/main.go", "pkg/server.go"}},
+		},
+		{
+			name: "unknown dependencies, self-references and duplicates are left out",
+			ast: []brain.FileNode{
+				{Path: "main.go", DependsOn: []string{"fmt", "main.go", "util.go", "util.go"}},
+				{Path: "util.go"},
+				{Path: "util.go"},
+			},
+			nodes: []string{"main.go", "util.go"},
+			edges: []Edge{{"main.go", "util.go"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("app", tt.ast)
+			var nodes []string
+			for _, n := range g.Nodes {
+				nodes = append(nodes, n.Path)
+			}
+			if !slices.Equal(nodes, tt.nodes) {
+				t.Errorf("nodes = %q, want %q", nodes, tt.nodes)
+			}
+			if !slices.Equal(g.Edges, tt.edges) {
+				t.Errorf("edges = %v, want %v", g.Edges, tt.edges)
+			}
+		})
+	}
+}
+
+func TestExport(t *testing.T) {
+	g := New("app", []brain.FileNode{
+		{Path: "main.go", Purpose: `Starts the "app"`, Action: brain.ActionCreate, DependsOn: []string{"util.go"}},
+		{Path: "util.go", Purpose: "Helpers", Action: brain.ActionModify},
+	})
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: FormatDOT,
+			want: `digraph "app" {
+  rankdir=LR;
+  node [shape=box, fontname="monospace"];
+  "main.go" [tooltip="Starts the \"app\""];
+  "util.go" [tooltip="Helpers", style=dashed];
+  "main.go" -> "util.go";
+}
+`,
+		},
+		{
+			format: FormatMermaid,
+			want: `flowchart LR
+  n0["main.go"]
+  n1["util.go"]
+  n0 --> n1
+  classDef modify stroke-dasharray: 5 5
+  class n1 modify
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			got, err := g.Export(tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Export(%q) =\n%s\nwant\n%s", tt.format, got, tt.want)
+			}
+		})
+	}
+
+	t.Run(FormatJSON, func(t *testing.T) {
+		got, err := g.Export(FormatJSON)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded Graph
+		if err := json.Unmarshal([]byte(got), &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if decoded.Version != Version || decoded.Name != "app" || len(decoded.Nodes) != 2 || len(decoded.Edges) != 1 {
+			t.Errorf("Export(%q) = %s", FormatJSON, got)
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		if _, err := g.Export("svg"); !goerrors.Is(err, errors.ErrUnknownFormat) {
+			t.Errorf("Export(%q) error = %v, want %v", "svg", err, errors.ErrUnknownFormat)
+		}
+	})
+}
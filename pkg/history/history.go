@@ -0,0 +1,163 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	goerrors "errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MaxEntries is how many entries are kept in the history file.
+const MaxEntries = 500
+
+type (
+	// History is the text entered in change requests and answers across
+	// sessions, oldest first.
+	History struct {
+		path    string
+		entries []Entry
+	}
+
+	Entry struct {
+		Time time.Time `json:"time"`
+		Text string    `json:"text"`
+	}
+
+	// Snippet is reusable text that can be inserted into a change request,
+	// e.g. "use table-driven tests".
+	Snippet struct {
+		Name string `yaml:"name"`
+		Text string `yaml:"text"`
+	}
+)
+
+// Dir returns the directory history and saved snippets are kept in, which is
+// devoid in the user config directory.
+func Dir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "devoid")
+}
+
+// Open reads the history in dir. A missing file is an empty history.
+func Open(dir string) (*History, error) {
+	h := &History{path: filepath.Join(dir, "history.jsonl")}
+	f, err := os.Open(h.path)
+	if goerrors.Is(err, fs.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		// Skip lines that can't be parsed rather than losing the rest.
+		if err := json.Unmarshal(scanner.Bytes(), &e); err == nil && e.Text != "" {
+			h.entries = append(h.entries, e)
+		}
+	}
+	return h, scanner.Err()
+}
+
+// Texts returns the text of each entry, oldest first.
+func (h *History) Texts() []string {
+	texts := make([]string, len(h.entries))
+	for i, e := range h.entries {
+		texts[i] = e.Text
+	}
+	return texts
+}
+
+// Add appends text to the history and saves it. An earlier entry with the
+// same text is moved to the end rather than repeated.
+func (h *History) Add(text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	h.entries = slices.DeleteFunc(h.entries, func(e Entry) bool { return e.Text == text })
+	h.entries = append(h.entries, Entry{Time: time.Now().UTC(), Text: text})
+	if len(h.entries) > MaxEntries {
+		h.entries = h.entries[len(h.entries)-MaxEntries:]
+	}
+
+	var b strings.Builder
+	for _, e := range h.entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		b.Write(line)
+		b.WriteString("\n")
+	}
+	return writeFile(h.path, []byte(b.String()))
+}
+
+// LoadSnippets reads the snippets saved in dir. A missing file means there
+// are none.
+func LoadSnippets(dir string) ([]Snippet, error) {
+	b, err := os.ReadFile(filepath.Join(dir, "snippets.yaml"))
+	if goerrors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snippets []Snippet
+	if err := yaml.Unmarshal(b, &snippets); err != nil {
+		return nil, err
+	}
+	return snippets, nil
+}
+
+// SaveSnippet adds s to the snippets saved in dir, replacing one with the
+// same name.
+func SaveSnippet(dir string, s Snippet) error {
+	snippets, err := LoadSnippets(dir)
+	if err != nil {
+		return err
+	}
+	if i := slices.IndexFunc(snippets, func(existing Snippet) bool { return existing.Name == s.Name }); i >= 0 {
+		snippets[i] = s
+	} else {
+		snippets = append(snippets, s)
+	}
+	b, err := yaml.Marshal(snippets)
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(dir, "snippets.yaml"), b)
+}
+
+// writeFile replaces path atomically. Entries may contain anything the user
+// typed, so the files are only readable by them.
+func writeFile(path string, b []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
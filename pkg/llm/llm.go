@@ -5,22 +5,29 @@ import (
 	"encoding/json"
 	goerrors "errors"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/zachwalton/devoid/pkg/audit"
+	"github.com/zachwalton/devoid/pkg/blueprints"
 	"github.com/zachwalton/devoid/pkg/brain"
 	"github.com/zachwalton/devoid/pkg/brain/schema"
 	"github.com/zachwalton/devoid/pkg/brain/templates"
+	"github.com/zachwalton/devoid/pkg/checkpoint"
 	"github.com/zachwalton/devoid/pkg/config"
 	"github.com/zachwalton/devoid/pkg/errors"
+	"github.com/zachwalton/devoid/pkg/git"
 	stagepkg "github.com/zachwalton/devoid/pkg/llm/stages"
+	"github.com/zachwalton/devoid/pkg/project"
+	"github.com/zachwalton/devoid/pkg/secrets"
 	"github.com/zachwalton/devoid/pkg/tui"
+	"github.com/zachwalton/devoid/pkg/validate"
 
 	"github.com/charmbracelet/log"
 )
 
 type (
-	templateFunc func(string) string
-
 	Reasoner interface {
 		Generate(context.Context, string, string, string) error
 		ResponseCh() <-chan Response
@@ -28,6 +35,10 @@ type (
 
 	HandlerFunc func(*brain.StagePayload, *config.Config) error
 
+	// ApplyFunc is called with the session's context, so anything it runs
+	// is cancelled along with the session.
+	ApplyFunc func(context.Context, *brain.StagePayload, *config.Config) error
+
 	Prompt struct {
 		Message        string
 		SystemTemplate string
@@ -40,134 +51,263 @@ type (
 	}
 
 	Stage struct {
-		Payload            *brain.StagePayload
-		LLM                bool
-		Description        string
-		Next               string
-		SystemTemplateFunc func(string) string
-		Schema             string
-		HandlerFunc        HandlerFunc
-		Final              bool
+		Payload     *brain.StagePayload
+		LLM         bool
+		Description string
+		Next        string
+		// Template is the name of the stage's system prompt template, and
+		// Summary the name of the template in brain.Summaries its result
+		// is shown with.
+		Template    string
+		Summary     string
+		Schema      string
+		HandlerFunc HandlerFunc
+		// Rules are semantic checks on the stage's result, run before
+		// HandlerFunc. Violations are sent back to the model to fix.
+		Rules []stagepkg.Rule
+		// ApplyFunc, if set, is called once the user accepts the stage's
+		// result, e.g. to run bootstrap commands.
+		ApplyFunc ApplyFunc
+		// Review is true for stages that write files, which the user reviews
+		// individually before they're applied.
+		Review bool
+		Final  bool
 	}
 )
 
 var (
 	stages = map[string]Stage{
 		"initial": {
-			LLM:                true,
-			Description:        "This stage analyzes the prompt and figures out things like language, frameworks, etc.",
-			SystemTemplateFunc: templates.SystemInitial,
-			Schema:             schema.SchemaInitial(),
-			Next:               "ast",
-			HandlerFunc:        stagepkg.HandleInitial,
+			LLM:         true,
+			Description: "This stage analyzes the prompt and figures out things like language, frameworks, etc.",
+			Template:    templates.Initial,
+			Summary:     "initial",
+			Schema:      schema.SchemaInitial(),
+			Next:        "ast",
+			HandlerFunc: stagepkg.HandleInitial,
+			ApplyFunc:   stagepkg.ApplyInitial,
+			Rules: []stagepkg.Rule{
+				stagepkg.NoForbiddenValues,
+				stagepkg.KnownFrameworks,
+				stagepkg.RegisteredNext,
+				stagepkg.NoProceedQuestions,
+			},
 		},
 		"ast": {
 			LLM:         true,
 			Description: "This stage creates an adjacency list / directed graph of the proposed codebase",
+			Template:    templates.AST,
+			Summary:     "ast",
+			Schema:      schema.SchemaAST(),
 			HandlerFunc: stagepkg.HandleAST,
-			Next:        "run",
+			Next:        "code",
+			Rules: []stagepkg.Rule{
+				stagepkg.NoForbiddenValues,
+				stagepkg.RegisteredNext,
+				stagepkg.NoProceedQuestions,
+			},
+		},
+		"code": {
+			LLM:         true,
+			Description: "This stage writes the content of every file in the proposed codebase",
+			Template:    templates.Code,
+			Summary:     "code",
+			Schema:      schema.SchemaCode(),
+			HandlerFunc: stagepkg.HandleCode,
+			ApplyFunc:   stagepkg.ApplyCode,
+			Review:      true,
 			Final:       true,
+			Rules: []stagepkg.Rule{
+				stagepkg.NoProceedQuestions,
+			},
 		},
 	}
 )
 
+// firstResult is the source of a stage's first iteration.
+const firstResult = "the model's first result"
+
 func Start(ctx context.Context, reasoner Reasoner, prompt, projectDir string, cfg *config.Config) chan bool {
 	doneCh := make(chan bool)
-	jsonCh := make(chan string)
 	stage := "initial"
 	choice := ""
 	choiceChanges := "Request changes in a live chat session"
 	choiceExit := "Exit the program"
 	choiceAnswers := "Answer some questions to help improve this result before proceeding"
 	choiceTryAgain := "I just don't like the response. Try again"
+	choiceEdit := "Edit the result directly"
+	choiceEarlier := "View the result of an earlier stage"
+	choiceBrowse := "Browse the planned files"
+	choiceCompare := "Compare with an earlier iteration"
 
-	var jsonResponse string
+	var (
+		jsonResponse string
+		input        string
+		seed         brain.StagePayload
+		// accepted holds the content of files the user accepted during review
+		// in the current stage, which is kept when the rest are regenerated.
+		accepted = map[string]string{}
+		// checkRetries counts consecutive static check failures.
+		checkRetries int
+		// edited is a response the user edited directly, which is used
+		// instead of asking the model again.
+		edited string
+		// iterations are the results of the current stage shown so far,
+		// and source describes what produced the next one.
+		iterations []stagepkg.Iteration
+		source     = firstResult
+	)
 	iteration := 1
-	log.Info("creating project...", "path", projectDir)
+	lib, err := blueprints.Load(cfg.BlueprintsDir)
+	if err != nil {
+		log.Error("could not load blueprints", "dir", cfg.BlueprintsDir, "error", err)
+		go func() { doneCh <- true }()
+		return doneCh
+	}
+	prompts, err := templates.Load(templates.Dirs(projectDir)...)
+	if err != nil {
+		log.Error("could not load prompt templates", "error", err)
+		go func() { doneCh <- true }()
+		return doneCh
+	}
+	for _, name := range templates.Names {
+		if origin := prompts.Origin(name); origin != templates.Embedded {
+			log.Info("using prompt template override", "template", name, "path", origin)
+		}
+	}
+	// Custom metadata fields only exist in the config, so the initial
+	// stage's schema is built from it for the session.
+	if len(cfg.Metadata.Fields) > 0 {
+		initial := stages["initial"]
+		initial.Schema, err = schema.SchemaInitialWith(cfg.Metadata.Fields)
+		if err != nil {
+			log.Error("could not add the custom metadata fields", "error", err)
+			go func() { doneCh <- true }()
+			return doneCh
+		}
+		stages["initial"] = initial
+	}
+	summaries, err := brain.LoadSummaries(templates.Dirs(projectDir)...)
+	if err != nil {
+		log.Error("could not load summary templates", "error", err)
+		go func() { doneCh <- true }()
+		return doneCh
+	}
+	for _, name := range brain.Summaries.Names {
+		if origin := summaries.Origin(name); origin != templates.Embedded {
+			log.Info("using summary template override", "template", name, "path", origin)
+		}
+	}
+	tmplCtx := &templates.Context{
+		ProjectDirectory: projectDir,
+		Blueprints:       lib.Catalog(),
+		CustomFields:     cfg.Metadata.Fields,
+	}
+	if cfg.Existing {
+		summary, err := project.Scan(projectDir)
+		if err != nil {
+			log.Error("could not scan existing project", "path", projectDir, "error", err)
+			go func() { doneCh <- true }()
+			return doneCh
+		}
+		if summary.Empty() {
+			log.Error("there's no existing project to evolve, run without --existing to create one", "path", projectDir)
+			go func() { doneCh <- true }()
+			return doneCh
+		}
+		log.Info("evolving existing project...", "path", projectDir, "files", summary.Files)
+		seed.Meta = summary.Meta()
+		tmplCtx.Existing = true
+		tmplCtx.RepoSummary = summary.String()
+		if b, err := json.Marshal(seed.Meta); err == nil {
+			tmplCtx.RepoSummary += "Detected metadata: " + string(b) + "\n"
+		}
+	} else {
+		log.Info("creating project...", "path", projectDir)
+	}
+	repo := openRepo(projectDir, cfg)
+	trail, err := audit.Open(projectDir)
+	if err != nil {
+		log.Warn("could not open the audit log, this session won't be recorded", "error", err)
+	}
+	trail.Record(audit.Event{Type: audit.EventSessionStart, Model: cfg.LLM.Model, Prompt: prompt})
+	timeline := newProgress()
 	go func() {
 		defer func() { doneCh <- true }()
+		defer trail.Close()
+		defer func() {
+			trail.Record(audit.Event{Type: audit.EventSessionEnd, Stage: stage, Iteration: iteration})
+		}()
 
 		for {
-			var payload brain.StagePayload
+			// Results carry forward from earlier stages; anything the model
+			// returns for this stage overrides them.
+			payload := brain.StagePayload{Meta: seed.Meta, AST: seed.AST}
+			tmplCtx.Input = input
 			log.Info("starting stage", "stage", stage, "description", stages[stage].Description, "iteration", iteration)
-			go func() {
-				var (
-					resp Response
-					sb   strings.Builder
-				)
-				for {
-					select {
-					case <-ctx.Done():
-						return
-					case resp = <-reasoner.ResponseCh():
-						sb.WriteString(resp.Response)
-					}
-					if resp.Done {
-						jsonCh <- sb.String()
-						return
-					}
+			timeline.running(stage, iteration)
+			if stages[stage].LLM && edited != "" {
+				jsonResponse, edited = edited, ""
+				if err := json.Unmarshal([]byte(jsonResponse), &payload); err != nil {
+					log.Error("got an error unmarshaling edited payload", "error", err)
+					timeline.finish(stage, tui.StageFailed)
+					return
 				}
-			}()
-
-			if iteration > 1 && choice != choiceTryAgain {
-				llmCtx, cancel := context.WithCancel(ctx)
-				fmt.Println()
-				spinner := tui.Spinner(llmCtx, cancel, "Working with the LLM on some changes...")
-				defer spinner.Stop()
-
-				if stages[stage].LLM {
-					if err := reasoner.Generate(
-						llmCtx, prompt,
-						stages[stage].Schema, templates.SystemClarify(jsonResponse),
-					); err != nil {
-						spinner.Stop()
-						log.Error("got an error during inference", "error", err)
-						return
-					}
-					spinner.Stop()
-
-					jsonResponse = <-jsonCh
-
-					if err := json.Unmarshal([]byte(jsonResponse), &payload); err != nil {
-						log.Error("got an error unmarshaling payload", "payload", jsonResponse, "error", err)
-						return
-					}
+			} else if stages[stage].LLM {
+				title, name := requestTemplate(stage, iteration, choice == choiceTryAgain)
+				tmplCtx.LastResponse = jsonResponse
+				system, err := prompts.Render(name, tmplCtx)
+				if err != nil {
+					log.Error("could not render the system prompt", "stage", stage, "error", err)
+					timeline.finish(stage, tui.StageFailed)
+					return
 				}
-			} else {
-				llmCtx, cancel := context.WithCancel(ctx)
-				fmt.Println()
-				spinner := tui.Spinner(llmCtx, cancel, "Chatting with the LLM...")
-				defer spinner.Stop()
-
-				if stages[stage].LLM {
-					if err := reasoner.Generate(
-						llmCtx, prompt, stages[stage].Schema,
-						stages[stage].SystemTemplateFunc(projectDir),
-					); err != nil {
-						spinner.Stop()
-						log.Error("got an error during inference", "error", err)
-						return
-					}
-					spinner.Stop()
-
-					jsonResponse = <-jsonCh
-
-					if err := json.Unmarshal([]byte(jsonResponse), &payload); err != nil {
-						log.Error("got an error unmarshaling payload", "payload", jsonResponse, "error", err)
-						return
+				response, ok := respond(ctx, reasoner, trail, stage, iteration, prompt, system, title, cfg)
+				if !ok {
+					timeline.finish(stage, tui.StageFailed)
+					return
+				}
+				jsonResponse = response
+				if err := parse(trail, stage, iteration, jsonResponse, &payload, cfg); err != nil {
+					if goerrors.Is(err, errors.ErrRecoverable) {
+						trail.Record(audit.Event{Type: audit.EventValidation, Stage: stage, Iteration: iteration, Error: err.Error()})
+						prompt = stagepkg.UpdatePromptForErr(stage, err)
+						iteration++
+						continue
 					}
+					log.Error("got an error unmarshaling payload", "error", err)
+					timeline.finish(stage, tui.StageFailed)
+					return
 				}
 			}
 
-			if stages[stage].LLM {
-				if iteration > 1 {
-					payload.StateMachine.ModifiedResult = true
+			for i, file := range payload.Files {
+				if content, ok := accepted[file.Path]; ok {
+					payload.Files[i].Content = content
 				}
-				tui.MarkdownView(payload.Markdown(stage, projectDir))
 			}
-
+			payload.Meta.CurrentStage = stage
 			payload.Meta.ProjectPath = projectDir
-			err := stages[stage].HandlerFunc(&payload, cfg)
+			payload.Meta.Existing = cfg.Existing
+			// Handlers run before the summary is shown so that it reflects
+			// anything they fill in, e.g. blueprint bootstrap commands.
+			err := handle(stage, &payload, cfg)
+			if err != nil {
+				trail.Record(audit.Event{Type: audit.EventValidation, Stage: stage, Iteration: iteration, Error: err.Error()})
+			}
+			// Static check failures are retried a limited number of times,
+			// after which the result is shown with its problems so the user
+			// can decide what to do.
+			if goerrors.Is(err, errors.ErrValidationFailed) {
+				checkRetries++
+				if checkRetries > retryBudget(cfg) {
+					log.Warn("static checks still fail after the retry budget was used up, showing the result as it is", "stage", stage, "retries", checkRetries-1)
+					err = nil
+				}
+			}
+			if err == nil {
+				checkRetries = 0
+			}
 			if err != nil {
 				switch {
 				case goerrors.Is(err, errors.ErrRecoverable):
@@ -176,20 +316,35 @@ func Start(ctx context.Context, reasoner Reasoner, prompt, projectDir string, cf
 					continue
 				default:
 					log.Error("got an error handling stage", "stage", stage, "error", err)
+					timeline.finish(stage, tui.StageFailed)
 					return
 				}
 			}
+
+			if stages[stage].LLM {
+				if iteration > 1 {
+					payload.StateMachine.ModifiedResult = true
+				}
+				tui.MarkdownView(summary(summaries, stage, &payload, projectDir))
+				iterations = append(iterations, stagepkg.Iteration{Number: iteration, Source: source, Response: jsonResponse})
+			}
+
 			log.Info("successfully applied stage", "stage", stage)
 			s := stages[stage]
 			s.Payload = &payload
 			stages[stage] = s
 
-			if stages[stage].Final {
-				log.Info("All stages have been completed!")
-				return
-			}
-
 			if !stages[stage].LLM {
+				if err := apply(ctx, trail, stage, iteration, &payload, cfg); err != nil {
+					timeline.finish(stage, tui.StageFailed)
+					return
+				}
+				record(repo, stage, iteration, &payload, cfg)
+				timeline.finish(stage, tui.StageAccepted)
+				if stages[stage].Final {
+					log.Info("All stages have been completed!")
+					return
+				}
 				stage = stages[stage].Next
 				prompt = stages[stage].Description
 				iteration = 1
@@ -197,6 +352,9 @@ func Start(ctx context.Context, reasoner Reasoner, prompt, projectDir string, cf
 			}
 
 			choiceMoveAhead := fmt.Sprintf("Move ahead to the '%s' stage", payload.StateMachine.Next)
+			if stages[stage].Final {
+				choiceMoveAhead = "Accept this result and finish"
+			}
 
 			choices := []string{
 				choiceMoveAhead,
@@ -204,58 +362,182 @@ func Start(ctx context.Context, reasoner Reasoner, prompt, projectDir string, cf
 				choiceTryAgain,
 				choiceExit,
 			}
+			if stagepkg.Editable(stage) {
+				choices = slices.Insert(choices, 2, choiceEdit)
+			}
+			if stagepkg.Browsable(&payload) {
+				choices = slices.Insert(choices, len(choices)-1, choiceBrowse)
+			}
+			if len(iterations) > 1 {
+				choices = slices.Insert(choices, len(choices)-1, choiceCompare)
+			}
+			if len(timeline.accepted()) > 0 {
+				choices = slices.Insert(choices, len(choices)-1, choiceEarlier)
+			}
 			if len(payload.StateMachine.Questions) != 0 {
 				choices = append([]string{choiceAnswers}, choices...)
 			}
 
 			selected := false
 			for !selected {
-				choice := tui.List(choices)
+				choice = tui.List(choices)
+				if tui.Interrupted() {
+					log.Info("exiting by user request...")
+					return
+				}
+				trail.Record(audit.Event{Type: audit.EventDecision, Stage: stage, Iteration: iteration, Decision: choice})
 
 				switch choice {
 				case choiceMoveAhead:
+					if stages[stage].Review {
+						feedback, ok, err := stagepkg.ReviewFiles(&payload, cfg, accepted)
+						if err != nil {
+							log.Error("got an error reviewing files", "stage", stage, "error", err)
+							timeline.finish(stage, tui.StageFailed)
+							return
+						}
+						if tui.Interrupted() {
+							log.Info("exiting by user request...")
+							return
+						}
+						if !ok {
+							continue
+						}
+						if feedback != "" {
+							trail.Record(audit.Event{Type: audit.EventDecision, Stage: stage, Iteration: iteration, Decision: audit.DecisionReview, Detail: feedback})
+							iteration++
+							prompt, source = feedback, "review feedback"
+							selected = true
+							continue
+						}
+					}
+					if err := apply(ctx, trail, stage, iteration, &payload, cfg); err != nil {
+						timeline.finish(stage, tui.StageFailed)
+						return
+					}
+					record(repo, stage, iteration, &payload, cfg)
+					timeline.finish(stage, tui.StageAccepted)
+					if stages[stage].Final {
+						log.Info("All stages have been completed!")
+						return
+					}
+					seed = brain.StagePayload{Meta: payload.Meta, AST: payload.AST}
+					accepted = map[string]string{}
+					tmplCtx.Blueprints = ""
+					tmplCtx.Blueprint = ""
+					tmplCtx.Files = stagepkg.FileContext(&payload, cfg)
+					if seed.Meta.Blueprint != "" && seed.Meta.Blueprint != blueprints.Unset {
+						if bp, err := lib.Get(seed.Meta.Blueprint, seed.Meta.Name); err == nil {
+							tmplCtx.Blueprint = bp.Skeleton()
+						}
+					}
+					if b, err := json.Marshal(payload); err == nil {
+						input = string(b)
+					}
 					stage = payload.StateMachine.Next
 					prompt = stages[stage].Description
 					selected = true
 					iteration = 1
+					iterations, source = nil, firstResult
 				case choiceChanges:
 					iteration++
 					var addendum string
 					for {
-						addendum = tui.Input("What do you want to ask or tell the model?")
+						addendum = stagepkg.Ask("What do you want to ask or tell the model?", cfg)
+						if tui.Interrupted() {
+							log.Info("exiting by user request...")
+							return
+						}
 						if addendum == "" {
 							log.Warn("You didn't enter any text! Try again...")
 							continue
 						}
 						break
 					}
+					trail.Record(audit.Event{Type: audit.EventDecision, Stage: stage, Iteration: iteration, Decision: audit.DecisionChangeRequest, Detail: addendum})
+					selected = true
+					prompt, source = addendum, "change request: "+addendum
+				case choiceEdit:
+					response, ok, err := stagepkg.Edit(stage, jsonResponse, stages[stage].Schema, func(response string) error {
+						return checkEdited(stage, response, seed, projectDir, cfg)
+					})
+					if tui.Interrupted() {
+						log.Info("exiting by user request...")
+						return
+					}
+					if err != nil {
+						log.Error("got an error editing the result", "stage", stage, "error", err)
+						continue
+					}
+					if !ok {
+						continue
+					}
+					iteration++
+					trail.Record(audit.Event{Type: audit.EventDecision, Stage: stage, Iteration: iteration, Decision: audit.DecisionEdit, Detail: redact("edited response", response, cfg)})
+					edited, source = response, "edited directly"
 					selected = true
-					prompt = addendum
+				case choiceBrowse:
+					response, ok, err := stagepkg.BrowseLayout(stage, jsonResponse, stages[stage].Schema, &payload, accepted, func(response string) error {
+						return checkEdited(stage, response, seed, projectDir, cfg)
+					})
+					if tui.Interrupted() {
+						log.Info("exiting by user request...")
+						return
+					}
+					if err != nil {
+						log.Error("got an error editing the planned files", "stage", stage, "error", err)
+						continue
+					}
+					if !ok {
+						continue
+					}
+					iteration++
+					trail.Record(audit.Event{Type: audit.EventDecision, Stage: stage, Iteration: iteration, Decision: audit.DecisionEdit, Detail: redact("edited response", response, cfg)})
+					edited, source = response, "edited the planned files"
+					selected = true
+				case choiceCompare:
+					revert, ok, err := stagepkg.CompareIterations(stage, iterations)
+					if tui.Interrupted() {
+						log.Info("exiting by user request...")
+						return
+					}
+					if err != nil {
+						log.Error("got an error comparing iterations", "stage", stage, "error", err)
+						continue
+					}
+					if !ok {
+						continue
+					}
+					iteration++
+					trail.Record(audit.Event{Type: audit.EventDecision, Stage: stage, Iteration: iteration, Decision: audit.DecisionRevert, Detail: fmt.Sprintf("iteration %d", revert.Number)})
+					edited, source = revert.Response, fmt.Sprintf("reverted to iteration %d", revert.Number)
+					selected = true
+				case choiceEarlier:
+					viewEarlier(timeline, summaries, projectDir)
+					if tui.Interrupted() {
+						log.Info("exiting by user request...")
+						return
+					}
 				case choiceExit:
 					log.Info("exiting by user request...")
 					return
 				case choiceAnswers:
-					iteration++
-					p := strings.Builder{}
-					for _, question := range payload.StateMachine.Questions {
-						var answer string
-						for {
-							answer = tui.Input(question)
-							if answer == "" {
-								log.Warn("You didn't enter any text! Try again...")
-								continue
-							}
-							break
-						}
-						p.WriteString("Question: ")
-						p.WriteString(question + "\n")
-						p.WriteString("Answer: ")
-						p.WriteString(answer + "\n")
+					answers, ok := stagepkg.AnswerQuestions(payload.StateMachine.Questions, cfg)
+					if tui.Interrupted() {
+						log.Info("exiting by user request...")
+						return
+					}
+					if !ok {
+						continue
 					}
+					iteration++
+					prompt = stagepkg.AnswersPrompt(answers)
+					trail.Record(audit.Event{Type: audit.EventDecision, Stage: stage, Iteration: iteration, Decision: audit.DecisionAnswers, Detail: prompt, Answers: answers})
 					selected = true
-					prompt = p.String()
+					source = "answers to the model's questions"
 				case choiceTryAgain:
 					iteration++
+					source = "tried again"
 					selected = true
 				}
 			}
@@ -263,3 +545,240 @@ func Start(ctx context.Context, reasoner Reasoner, prompt, projectDir string, cf
 	}()
 	return doneCh
 }
+
+// summary renders the stage's summary. If its template fails, the error is
+// shown along with the result as JSON, so the user can still review it.
+func summary(summaries *templates.Library, stage string, payload *brain.StagePayload, projectDir string) string {
+	md, err := payload.Markdown(summaries, stages[stage].Summary, stage, projectDir)
+	if err == nil {
+		return md
+	}
+	log.Error("could not render the summary", "stage", stage, "error", err)
+	b, _ := json.MarshalIndent(payload, "", "  ")
+	return fmt.Sprintf("# Could not render the summary\n\n> %s\n\nThis is the result as JSON:\n\n```json\n%s\n```\n", err, b)
+}
+
+// requestTemplate returns the progress title and the system template for a
+// request to the model. Later iterations ask for changes to the last result
+// with the clarify template, unless the user asked to try again, which
+// regenerates the result from scratch.
+func requestTemplate(stage string, iteration int, tryAgain bool) (title, name string) {
+	if iteration > 1 && !tryAgain {
+		return "Working with the LLM on some changes...", templates.Clarify
+	}
+	return "Chatting with the LLM...", stages[stage].Template
+}
+
+// checkEdited runs the stage's handler on a response the user edited, so it's
+// validated the same way as the model's.
+func checkEdited(stage, response string, seed brain.StagePayload, projectDir string, cfg *config.Config) error {
+	payload := brain.StagePayload{Meta: seed.Meta, AST: seed.AST}
+	if err := json.Unmarshal([]byte(response), &payload); err != nil {
+		return err
+	}
+	payload.Meta.CurrentStage = stage
+	payload.Meta.ProjectPath = projectDir
+	payload.Meta.Existing = cfg.Existing
+	return handle(stage, &payload, cfg)
+}
+
+// handle checks the stage's rules and then runs its handler.
+func handle(stage string, payload *brain.StagePayload, cfg *config.Config) error {
+	names := make([]string, 0, len(stages))
+	for name := range stages {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	rc := stagepkg.RuleContext{Stages: names, Final: stages[stage].Final, CustomFields: cfg.Metadata.Fields}
+	if err := stagepkg.CheckRules(stages[stage].Rules, payload, rc); err != nil {
+		return err
+	}
+	return stages[stage].HandlerFunc(payload, cfg)
+}
+
+func retryBudget(cfg *config.Config) int {
+	if cfg.Validation.Retries > 0 {
+		return cfg.Validation.Retries
+	}
+	return validate.DefaultRetries
+}
+
+func apply(ctx context.Context, trail *audit.Log, stage string, iteration int, payload *brain.StagePayload, cfg *config.Config) error {
+	if stages[stage].ApplyFunc == nil {
+		return nil
+	}
+	err := stages[stage].ApplyFunc(ctx, payload, cfg)
+	// Commands and writes are recorded even when applying fails partway, since
+	// those that already happened can't be undone.
+	for i := range payload.Commands {
+		if payload.Commands[i].Status != "" {
+			trail.Record(audit.Event{Type: audit.EventCommand, Stage: stage, Iteration: iteration, Command: &payload.Commands[i]})
+		}
+	}
+	for i := range payload.Writes {
+		trail.Record(audit.Event{Type: audit.EventWrite, Stage: stage, Iteration: iteration, Write: &payload.Writes[i]})
+	}
+	if err != nil {
+		trail.Record(audit.Event{Type: audit.EventError, Stage: stage, Iteration: iteration, Error: err.Error()})
+		log.Error("got an error applying stage", "stage", stage, "error", err)
+		return err
+	}
+	return nil
+}
+
+// respond sends a request to the model, recording it in the audit log, and
+// streams the response to the terminal as it's generated. It returns false if
+// the request failed or was cancelled.
+func respond(ctx context.Context, reasoner Reasoner, trail *audit.Log, stage string, iteration int, prompt, system, title string, cfg *config.Config) (string, bool) {
+	trail.Record(audit.Event{
+		Type:       audit.EventRequest,
+		Stage:      stage,
+		Iteration:  iteration,
+		Model:      cfg.LLM.Model,
+		Prompt:     prompt,
+		SystemHash: audit.Hash(system),
+		Schema:     stages[stage].Schema,
+	})
+
+	llmCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream := tui.StartStream(llmCtx, cancel, title)
+	defer stream.Stop()
+
+	responseCh := make(chan string, 1)
+	go func() {
+		var sb strings.Builder
+		for {
+			select {
+			case <-llmCtx.Done():
+				return
+			case resp := <-reasoner.ResponseCh():
+				sb.WriteString(resp.Response)
+				stream.Write(resp.Response)
+				if resp.Done {
+					responseCh <- sb.String()
+					return
+				}
+			}
+		}
+	}()
+
+	if err := reasoner.Generate(llmCtx, prompt, stages[stage].Schema, system); err != nil {
+		trail.Record(audit.Event{Type: audit.EventError, Stage: stage, Iteration: iteration, Error: err.Error()})
+		log.Error("got an error during inference", "error", err)
+		return "", false
+	}
+	select {
+	case response := <-responseCh:
+		return response, true
+	case <-llmCtx.Done():
+		trail.Record(audit.Event{Type: audit.EventError, Stage: stage, Iteration: iteration, Error: "request was cancelled"})
+		log.Warn("the request was cancelled")
+		return "", false
+	}
+}
+
+// parse validates a response against the stage's schema and unmarshals it
+// into payload, recording both in the audit log with any likely secrets
+// redacted. Responses that don't match the schema return an ErrRecoverable
+// listing what's wrong, so the model can be asked to fix it.
+func parse(trail *audit.Log, stage string, iteration int, response string, payload *brain.StagePayload, cfg *config.Config) error {
+	e := audit.Event{Type: audit.EventResponse, Stage: stage, Iteration: iteration, Response: response}
+	root, err := schema.Parse(stages[stage].Schema)
+	if err == nil {
+		err = root.Check(response)
+	}
+	if err == nil {
+		err = json.Unmarshal([]byte(response), payload)
+	}
+	if err != nil {
+		e.Error = err.Error()
+	} else if b, err := json.Marshal(payload); err == nil {
+		e.Payload = b
+	}
+	if scanner, err := secrets.New(cfg.Secrets); err == nil {
+		e.Response = secrets.Redact(e.Response, scanner.Scan("response", e.Response))
+		if e.Payload != nil {
+			redacted := secrets.Redact(string(e.Payload), scanner.Scan("payload", string(e.Payload)))
+			if json.Valid([]byte(redacted)) {
+				e.Payload = json.RawMessage(redacted)
+			} else {
+				e.Payload = nil
+			}
+		}
+	}
+	trail.Record(e)
+	return err
+}
+
+// redact returns content with any likely secrets redacted, for the audit log.
+func redact(name, content string, cfg *config.Config) string {
+	scanner, err := secrets.New(cfg.Secrets)
+	if err != nil {
+		return content
+	}
+	return secrets.Redact(content, scanner.Scan(name, content))
+}
+
+// openRepo returns the project's git repository, initializing one if needed,
+// or nil if git is disabled or unusable for this session.
+func openRepo(projectDir string, cfg *config.Config) *git.Repo {
+	if cfg.NoGit {
+		return nil
+	}
+	repo, err := git.Open(projectDir)
+	if err != nil {
+		log.Warn("could not open a git repository, stages won't be committed", "path", projectDir, "error", err)
+		return nil
+	}
+	// Session logs are local to the machine and are written throughout the
+	// session, so they're never committed. The pattern matches at any depth
+	// since the project may be a subdirectory of the repository.
+	if err := repo.Exclude("**/" + audit.SessionsDir + "/"); err != nil {
+		log.Warn("could not exclude session logs from git", "error", err)
+	}
+	if !repo.HasCommits() {
+		if _, err := repo.Commit("devoid: baseline", ""); err != nil {
+			log.Warn("could not commit the project baseline, stages won't be committed", "error", err)
+			return nil
+		}
+		return repo
+	}
+	// Committing would sweep the user's own uncommitted work into devoid's
+	// commits, and rolling back would discard it.
+	if dirty, err := repo.Dirty(); err != nil || dirty {
+		log.Warn("the project has uncommitted changes, stages won't be committed", "path", projectDir)
+		return nil
+	}
+	return repo
+}
+
+// record saves a checkpoint for an accepted stage and commits it.
+func record(repo *git.Repo, stage string, iteration int, payload *brain.StagePayload, cfg *config.Config) {
+	if err := checkpoint.Save(cfg.ProjectPath, &checkpoint.Checkpoint{
+		Stage:     stage,
+		Iteration: iteration,
+		Model:     cfg.LLM.Model,
+		Prompt:    cfg.Prompt,
+		Time:      time.Now(),
+		Payload:   payload,
+		Writes:    payload.Writes,
+	}); err != nil {
+		log.Warn("could not save checkpoint", "stage", stage, "error", err)
+	}
+	if repo == nil {
+		return
+	}
+	hash, err := repo.CommitStage(git.Message{
+		Stage:       stage,
+		Description: stages[stage].Description,
+		Iteration:   iteration,
+		Model:       cfg.LLM.Model,
+		Prompt:      cfg.Prompt,
+	})
+	if err != nil {
+		log.Warn("could not commit stage", "stage", stage, "error", err)
+		return
+	}
+	log.Info("committed stage", "stage", stage, "commit", hash[:min(len(hash), 12)])
+}
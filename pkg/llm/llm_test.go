@@ -0,0 +1,43 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zachwalton/devoid/pkg/brain/templates"
+	"github.com/zachwalton/devoid/pkg/config"
+)
+
+func TestRequestTemplate(t *testing.T) {
+	tests := []struct {
+		name      string
+		stage     string
+		iteration int
+		tryAgain  bool
+		want      string
+	}{
+		{name: "first result", stage: "initial", iteration: 1, want: templates.Initial},
+		{name: "changes", stage: "initial", iteration: 2, want: templates.Clarify},
+		{name: "try again", stage: "ast", iteration: 2, tryAgain: true, want: templates.AST},
+		{name: "try again after changes", stage: "code", iteration: 5, tryAgain: true, want: templates.Code},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := requestTemplate(tt.stage, tt.iteration, tt.tryAgain); got != tt.want {
+				t.Errorf("requestTemplate(%q, %d, %v) = %q, want %q", tt.stage, tt.iteration, tt.tryAgain, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	token := "ghp_" + strings.Repeat("a1B2", 9)
+	response := `{"bootstrap":["git clone https://` + token + `@github.com/me/app"]}`
+	got := redact("edited response", response, &config.Config{})
+	if strings.Contains(got, token) {
+		t.Errorf("redact() = %q, want the token redacted", got)
+	}
+	if !strings.Contains(got, "github.com/me/app") {
+		t.Errorf("redact() = %q, want the rest kept", got)
+	}
+}
@@ -0,0 +1,95 @@
+package llm
+
+import (
+	"time"
+
+	"github.com/zachwalton/devoid/pkg/brain/templates"
+	"github.com/zachwalton/devoid/pkg/tui"
+)
+
+// progress tracks the status of every stage for the timeline shown beside
+// each screen.
+type progress struct {
+	stages []*tui.TimelineStage
+}
+
+// newProgress lists the stages in the order they run, following Next from
+// the initial stage.
+func newProgress() *progress {
+	p := &progress{}
+	for name := "initial"; name != "" && p.get(name) == nil; name = stages[name].Next {
+		p.stages = append(p.stages, &tui.TimelineStage{Name: name, Status: tui.StagePending})
+		if stages[name].Final {
+			break
+		}
+	}
+	return p
+}
+
+func (p *progress) get(name string) *tui.TimelineStage {
+	for _, s := range p.stages {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// running marks a stage as running its given iteration. Stages the model
+// moves to that aren't in the registry's order are added to the end.
+func (p *progress) running(name string, iteration int) {
+	s := p.get(name)
+	if s == nil {
+		s = &tui.TimelineStage{Name: name}
+		p.stages = append(p.stages, s)
+	}
+	if s.Status != tui.StageRunning {
+		s.Status, s.Started = tui.StageRunning, time.Now()
+	}
+	s.Iterations = iteration
+	p.show()
+}
+
+// finish marks a running stage as accepted or failed.
+func (p *progress) finish(name string, status tui.StageStatus) {
+	s := p.get(name)
+	if s == nil || s.Status != tui.StageRunning {
+		return
+	}
+	s.Status, s.Duration = status, time.Since(s.Started)
+	p.show()
+}
+
+// accepted returns the names of the stages the user has accepted, in order.
+func (p *progress) accepted() []string {
+	var names []string
+	for _, s := range p.stages {
+		if s.Status == tui.StageAccepted {
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
+
+func (p *progress) show() {
+	timeline := make([]tui.TimelineStage, len(p.stages))
+	for i, s := range p.stages {
+		timeline[i] = *s
+	}
+	tui.SetTimeline(timeline)
+}
+
+// viewEarlier lets the user pick stages they've accepted and shows the
+// summary of each, until they go back.
+func viewEarlier(p *progress, summaries *templates.Library, projectDir string) {
+	choiceBack := "Back"
+	for {
+		choice := tui.ListWithTitle("Which stage do you want to view?", append(p.accepted(), choiceBack))
+		if choice == "" || choice == choiceBack {
+			return
+		}
+		if payload := stages[choice].Payload; payload != nil {
+			tui.MarkdownView(summary(summaries, choice, payload, projectDir))
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package stages
+
+import (
+	"github.com/charmbracelet/log"
+	"github.com/zachwalton/devoid/pkg/config"
+	"github.com/zachwalton/devoid/pkg/history"
+	"github.com/zachwalton/devoid/pkg/tui"
+)
+
+// Ask asks the user for text that may span several lines, e.g. a change
+// request, offering their input history and the snippets from the config
+// file and earlier sessions. It returns an empty string if they skipped it.
+func Ask(prompt string, cfg *config.Config) string {
+	dir := history.Dir()
+	var snippets []tui.Snippet
+	for _, s := range cfg.Snippets {
+		snippets = append(snippets, tui.Snippet{Name: s.Name, Text: s.Text})
+	}
+	var inputs *history.History
+	if dir != "" {
+		saved, err := history.LoadSnippets(dir)
+		if err != nil {
+			log.Warn("could not load saved snippets", "dir", dir, "error", err)
+		}
+		for _, s := range saved {
+			snippets = append(snippets, tui.Snippet{Name: s.Name, Text: s.Text})
+		}
+		if !cfg.NoHistory {
+			if inputs, err = history.Open(dir); err != nil {
+				log.Warn("could not load the input history", "dir", dir, "error", err)
+			}
+		}
+	}
+
+	var texts []string
+	if inputs != nil {
+		texts = inputs.Texts()
+	}
+	composed := tui.Compose(prompt, texts, snippets)
+	for _, s := range composed.Saved {
+		if dir == "" {
+			log.Warn("could not save snippet, there's no user config directory", "name", s.Name)
+			continue
+		}
+		if err := history.SaveSnippet(dir, history.Snippet{Name: s.Name, Text: s.Text}); err != nil {
+			log.Warn("could not save snippet", "name", s.Name, "error", err)
+		}
+	}
+	if inputs != nil && composed.Text != "" {
+		if err := inputs.Add(composed.Text); err != nil {
+			log.Warn("could not save the input history", "dir", dir, "error", err)
+		}
+	}
+	return composed.Text
+}
@@ -1,10 +1,67 @@
 package stages
 
 import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
 	"github.com/zachwalton/devoid/pkg/brain"
 	"github.com/zachwalton/devoid/pkg/config"
+	"github.com/zachwalton/devoid/pkg/errors"
 )
 
 func HandleAST(payload *brain.StagePayload, cfg *config.Config) error {
+	if len(payload.AST) == 0 {
+		return fmt.Errorf("%w: ast was empty, it must list every file in the project", errors.ErrRecoverable)
+	}
+
+	nodes := map[string]bool{}
+	for i, node := range payload.AST {
+		p := path.Clean(filepath.ToSlash(node.Path))
+		if node.Path == "" || path.IsAbs(p) || p == "." || p == ".." || strings.HasPrefix(p, "../") {
+			return fmt.Errorf("%w: ast -> %d -> path %q must be a relative path inside the project directory", errors.ErrRecoverable, i, node.Path)
+		}
+		if nodes[p] {
+			return fmt.Errorf("%w: ast -> %d -> path %q is listed more than once", errors.ErrRecoverable, i, node.Path)
+		}
+		nodes[p] = true
+		payload.AST[i].Path = p
+
+		_, statErr := os.Stat(filepath.Join(cfg.ProjectPath, filepath.FromSlash(p)))
+		exists := statErr == nil
+		switch node.Action {
+		case brain.ActionCreate:
+			if exists {
+				return fmt.Errorf("%w: ast -> %d -> %q already exists, so its action must be 'modify'", errors.ErrRecoverable, i, p)
+			}
+		case brain.ActionModify:
+			if !exists {
+				return fmt.Errorf("%w: ast -> %d -> %q doesn't exist yet, so its action must be 'create'", errors.ErrRecoverable, i, p)
+			}
+		default:
+			return fmt.Errorf("%w: ast -> %d -> action must be 'create' or 'modify', got %q", errors.ErrRecoverable, i, node.Action)
+		}
+	}
+
+	for i, node := range payload.AST {
+		for _, dep := range node.DependsOn {
+			dep = path.Clean(filepath.ToSlash(dep))
+			if dep == node.Path {
+				return fmt.Errorf("%w: ast -> %d -> %q depends on itself", errors.ErrRecoverable, i, node.Path)
+			}
+			if !nodes[dep] {
+				return fmt.Errorf("%w: ast -> %d -> %q depends on %q, which isn't in the ast", errors.ErrRecoverable, i, node.Path, dep)
+			}
+		}
+	}
+
+	log.Info(
+		"completed validations on the proposed file graph",
+		"stage", payload.Meta.CurrentStage,
+		"files", len(payload.AST),
+	)
 	return nil
 }
@@ -0,0 +1,180 @@
+package stages
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/zachwalton/devoid/pkg/brain"
+	"github.com/zachwalton/devoid/pkg/config"
+	"github.com/zachwalton/devoid/pkg/errors"
+	"github.com/zachwalton/devoid/pkg/secrets"
+	"github.com/zachwalton/devoid/pkg/validate"
+	"github.com/zachwalton/devoid/pkg/workspace"
+)
+
+// maxContextFileBytes caps how much of each existing file is included in the
+// prompt for files the code stage modifies.
+const maxContextFileBytes = 16 * 1024
+
+func HandleCode(payload *brain.StagePayload, cfg *config.Config) error {
+	expected := map[string]bool{}
+	for _, node := range payload.AST {
+		expected[node.Path] = true
+	}
+
+	seen := map[string]bool{}
+	for i, file := range payload.Files {
+		p := path.Clean(filepath.ToSlash(file.Path))
+		if !expected[p] {
+			return fmt.Errorf("%w: files -> %d -> %q isn't in the ast, only files from the ast may be written", errors.ErrRecoverable, i, file.Path)
+		}
+		if seen[p] {
+			return fmt.Errorf("%w: files -> %d -> %q is listed more than once", errors.ErrRecoverable, i, file.Path)
+		}
+		if strings.TrimSpace(file.Content) == "" {
+			return fmt.Errorf("%w: files -> %d -> %q has no content", errors.ErrRecoverable, i, file.Path)
+		}
+		seen[p] = true
+		payload.Files[i].Path = p
+	}
+
+	var missing []string
+	for _, node := range payload.AST {
+		if !seen[node.Path] {
+			missing = append(missing, node.Path)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: files is missing content for %s", errors.ErrRecoverable, strings.Join(missing, ", "))
+	}
+
+	if err := scanFiles(payload, cfg); err != nil {
+		return err
+	}
+	if err := validateFiles(payload, cfg); err != nil {
+		return err
+	}
+
+	log.Info(
+		"completed validations on generated files",
+		"stage", payload.Meta.CurrentStage,
+		"files", len(payload.Files),
+	)
+	return nil
+}
+
+// scanFiles redacts likely secrets from the generated files, or sends them back
+// to the model if secrets are configured to be blocked.
+func scanFiles(payload *brain.StagePayload, cfg *config.Config) error {
+	scanner, err := secrets.New(cfg.Secrets)
+	if err != nil {
+		return err
+	}
+	payload.Findings = nil
+	for i, file := range payload.Files {
+		findings := scanner.Scan(file.Path, file.Content)
+		if len(findings) == 0 {
+			continue
+		}
+		if scanner.Block() {
+			return fmt.Errorf(
+				"%w: files -> %d -> %q contains what looks like a %s on line %d. Never hardcode secrets, read them from the environment or use an obvious placeholder instead",
+				errors.ErrRecoverable, i, file.Path, findings[0].Description, findings[0].Line,
+			)
+		}
+		for _, f := range findings {
+			log.Warn("redacted a likely secret", "path", f.Path, "line", f.Line, "rule", f.Rule)
+		}
+		payload.Files[i].Content = secrets.Redact(file.Content, findings)
+		payload.Findings = append(payload.Findings, findings...)
+	}
+	return nil
+}
+
+// validateFiles runs static checks on the generated files. Diagnostics are
+// kept on the payload so they can be shown if the model runs out of retries.
+func validateFiles(payload *brain.StagePayload, cfg *config.Config) error {
+	payload.Diagnostics = nil
+	if cfg.Validation.Disabled {
+		return nil
+	}
+	v, err := validate.New(cfg.Validation, cfg.Sandbox)
+	if err != nil {
+		return err
+	}
+	files := map[string]string{}
+	for _, file := range payload.Files {
+		files[file.Path] = file.Content
+	}
+	diagnostics, err := v.Validate(context.Background(), cfg.ProjectPath, files)
+	if err != nil {
+		return err
+	}
+	for i, file := range payload.Files {
+		payload.Files[i].Content = files[file.Path]
+	}
+	if len(diagnostics) == 0 {
+		return nil
+	}
+	payload.Diagnostics = diagnostics
+	return validate.Error(diagnostics)
+}
+
+// ApplyCode writes the generated files through the workspace, stopping if the
+// session is cancelled. Existing files may only be overwritten if the accepted
+// ast marked them for modification.
+func ApplyCode(ctx context.Context, payload *brain.StagePayload, cfg *config.Config) error {
+	ws, err := workspace.New(cfg.ProjectPath)
+	if err != nil {
+		return err
+	}
+	for _, node := range payload.AST {
+		if node.Action == brain.ActionModify {
+			if err := ws.Allow(node.Path); err != nil {
+				return err
+			}
+		}
+	}
+	for _, file := range payload.Files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		w, err := ws.Write(file.Path, []byte(file.Content))
+		if err != nil {
+			return err
+		}
+		log.Info("wrote file", "path", w.Path, "action", w.Action, "bytes", w.Bytes)
+	}
+	payload.Writes = ws.Writes()
+	return nil
+}
+
+// FileContext renders the current content of the files an accepted ast
+// modifies, for use in the code stage's prompt.
+func FileContext(payload *brain.StagePayload, cfg *config.Config) string {
+	ws, err := workspace.New(cfg.ProjectPath)
+	if err != nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, node := range payload.AST {
+		if node.Action != brain.ActionModify {
+			continue
+		}
+		content, err := ws.Read(node.Path)
+		if err != nil {
+			log.Warn("could not read file to modify", "path", node.Path, "error", err)
+			continue
+		}
+		truncated := ""
+		if len(content) > maxContextFileBytes {
+			content, truncated = content[:maxContextFileBytes], "\n(truncated)"
+		}
+		fmt.Fprintf(&b, "=== %s ===\n%s%s\n", node.Path, content, truncated)
+	}
+	return b.String()
+}
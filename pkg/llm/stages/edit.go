@@ -0,0 +1,226 @@
+package stages
+
+import (
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/zachwalton/devoid/pkg/blueprints"
+	"github.com/zachwalton/devoid/pkg/brain/schema"
+	"github.com/zachwalton/devoid/pkg/errors"
+	"github.com/zachwalton/devoid/pkg/tui"
+)
+
+var (
+	metaEditable = []string{
+		"name", "description", "language", "framework", "architecture", "database", "test", "blueprint",
+		"runtime_version", "package_manager", "code_style", "license", "ci", "deployment",
+	}
+	nodeEditable = []string{"path", "purpose", "action", "depends_on"}
+)
+
+// Editable returns true if the stage has structured fields the user can edit
+// directly.
+func Editable(stage string) bool {
+	return stage == "initial" || stage == "ast"
+}
+
+// editPointers returns the JSON pointers of the fields that can be edited in
+// the stage's response, including any custom metadata fields in its schema.
+func editPointers(stage string, root *schema.Node, doc map[string]any) []string {
+	var pointers []string
+	switch stage {
+	case "initial":
+		for _, key := range metaEditable {
+			pointers = append(pointers, schema.Pointer("/meta", key))
+		}
+		if custom := root.Lookup("/meta/custom"); custom != nil {
+			for _, name := range slices.Sorted(maps.Keys(custom.Properties)) {
+				pointers = append(pointers, schema.Pointer("/meta/custom", name))
+			}
+		}
+		// A blueprint's bootstrap commands replace the model's when the
+		// result is handled, so they're only editable without one.
+		if blueprint, _ := schema.Get(doc, "/meta/blueprint"); blueprint == nil || blueprint == "" || blueprint == blueprints.Unset {
+			pointers = append(pointers, "/bootstrap")
+		}
+	case "ast":
+		nodes, _ := doc["ast"].([]any)
+		for i := range nodes {
+			for _, key := range nodeEditable {
+				pointers = append(pointers, schema.Pointer("/ast", strconv.Itoa(i), key))
+			}
+		}
+	}
+	return pointers
+}
+
+// Edit lets the user edit the structured fields of a stage's response in a
+// form, so small corrections don't need another round-trip to the model. The
+// edited response is validated against the stage's schema and then by check,
+// normally the stage's handler, and the form is shown again with any problems.
+// It returns the edited response, or false if the user cancelled or didn't
+// change anything.
+func Edit(stage, response, stageSchema string, check func(response string) error) (string, bool, error) {
+	root, err := schema.Parse(stageSchema)
+	if err != nil {
+		return "", false, err
+	}
+	var original map[string]any
+	if err := json.Unmarshal([]byte(response), &original); err != nil {
+		return "", false, fmt.Errorf("could not parse the %s stage's response: %w", stage, err)
+	}
+
+	var fields []tui.Field
+	for _, pointer := range editPointers(stage, root, original) {
+		fields = append(fields, editField(root, original, pointer))
+	}
+	if len(fields) == 0 {
+		return "", false, fmt.Errorf("the %s stage has no fields that can be edited", stage)
+	}
+
+	problem := ""
+	for {
+		edited, ok := tui.Form(fmt.Sprintf("Edit the %s stage", stage), problem, fields)
+		if !ok {
+			return "", false, nil
+		}
+		fields, problem = edited, ""
+
+		// Start from the original each time so values from earlier attempts
+		// don't linger.
+		var doc map[string]any
+		json.Unmarshal([]byte(response), &doc)
+		var changed []string
+		for _, f := range fields {
+			value := fieldValue(root.Lookup(f.Key), f.Value)
+			before, exists := schema.Get(doc, f.Key)
+			if (!exists && f.Value == "") || sameValue(before, value) {
+				continue
+			}
+			changed = append(changed, f.Label)
+			if err := schema.Set(doc, f.Key, value); err != nil {
+				return "", false, err
+			}
+		}
+		if len(changed) == 0 {
+			log.Info("nothing was changed", "stage", stage)
+			return "", false, nil
+		}
+
+		// Only the edited fields are checked, since the rest of the response
+		// was already accepted when the model returned it.
+		invalid := false
+		for _, v := range append(root.Validate(doc), emptyRequired(root, original, fields)...) {
+			i := slices.IndexFunc(fields, func(f tui.Field) bool {
+				return v.Pointer == f.Key || strings.HasPrefix(v.Pointer, f.Key+"/")
+			})
+			if i >= 0 {
+				fields[i].Error = v.Message
+				invalid = true
+			}
+		}
+		if invalid {
+			continue
+		}
+
+		schema.Set(doc, "/state_machine/description", "Edited directly: "+strings.Join(changed, ", "))
+		b, err := json.Marshal(doc)
+		if err != nil {
+			return "", false, err
+		}
+		if err := check(string(b)); err != nil {
+			if !goerrors.Is(err, errors.ErrRecoverable) {
+				return "", false, err
+			}
+			problem = strings.TrimPrefix(err.Error(), errors.ErrRecoverable.Error()+": ")
+			continue
+		}
+		return string(b), true, nil
+	}
+}
+
+func editField(root *schema.Node, doc map[string]any, pointer string) tui.Field {
+	tokens := schema.Tokens(pointer)
+	key := tokens[len(tokens)-1]
+	label := strings.ReplaceAll(key, "_", " ")
+	label = strings.ToUpper(label[:1]) + label[1:]
+	if tokens[0] == "ast" {
+		// Label node fields with the node's original path, since that's how
+		// they appear in the summary.
+		path, _ := schema.Get(doc, schema.Pointer("/ast", tokens[1], "path"))
+		label = fmt.Sprintf("%v: %s", path, strings.ToLower(label))
+	}
+
+	f := tui.Field{Key: pointer, Label: label}
+	n := root.Lookup(pointer)
+	if n != nil {
+		f.Description = n.Description
+		for _, e := range n.Enum {
+			f.Options = append(f.Options, fmt.Sprint(e))
+		}
+		f.Multiline = n.Type == "array"
+	}
+	switch v, _ := schema.Get(doc, pointer); v := v.(type) {
+	case string:
+		f.Value = v
+	case []any:
+		var lines []string
+		for _, item := range v {
+			lines = append(lines, fmt.Sprint(item))
+		}
+		f.Value = strings.Join(lines, "\n")
+	case nil:
+	default:
+		f.Value = fmt.Sprint(v)
+	}
+	if f.Multiline {
+		f.Description = strings.TrimSpace(f.Description + " One per line.")
+	}
+	return f
+}
+
+// fieldValue converts a field's value to the type its schema expects. Arrays
+// are edited one item per line.
+func fieldValue(n *schema.Node, value string) any {
+	if n == nil || n.Type != "array" {
+		return strings.TrimSpace(value)
+	}
+	items := []any{}
+	for _, line := range strings.Split(value, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			items = append(items, line)
+		}
+	}
+	return items
+}
+
+func sameValue(a, b any) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return string(ja) == string(jb)
+}
+
+// emptyRequired reports required string fields that were cleared, which the
+// schema alone allows.
+func emptyRequired(root *schema.Node, original map[string]any, fields []tui.Field) []schema.Violation {
+	var violations []schema.Violation
+	for _, f := range fields {
+		tokens := schema.Tokens(f.Key)
+		parent := root.Lookup(schema.Pointer("", tokens[:len(tokens)-1]...))
+		n := root.Lookup(f.Key)
+		if parent == nil || n == nil || n.Type != "string" {
+			continue
+		}
+		before, _ := schema.Get(original, f.Key)
+		if slices.Contains(parent.Required, tokens[len(tokens)-1]) && strings.TrimSpace(f.Value) == "" && before != "" && before != nil {
+			violations = append(violations, schema.Violation{Pointer: f.Key, Message: "can't be empty"})
+		}
+	}
+	return violations
+}
@@ -0,0 +1,36 @@
+package stages
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/zachwalton/devoid/pkg/brain/schema"
+)
+
+func TestEditPointersBootstrap(t *testing.T) {
+	root, err := schema.Parse(schema.SchemaInitial())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		blueprint any
+		want      bool
+	}{
+		{name: "no blueprint", want: true},
+		{name: "unset blueprint", blueprint: "unset", want: true},
+		{name: "blueprint", blueprint: "go-cli"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := map[string]any{"name": "todo"}
+			if tt.blueprint != nil {
+				meta["blueprint"] = tt.blueprint
+			}
+			pointers := editPointers("initial", root, map[string]any{"meta": meta})
+			if got := slices.Contains(pointers, "/bootstrap"); got != tt.want {
+				t.Errorf("editPointers() includes /bootstrap = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
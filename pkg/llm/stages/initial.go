@@ -1,22 +1,187 @@
 package stages
 
 import (
-  "fmt"
+	"context"
+	goerrors "errors"
+	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/log"
+	"github.com/zachwalton/devoid/pkg/blueprints"
 	"github.com/zachwalton/devoid/pkg/brain"
 	"github.com/zachwalton/devoid/pkg/config"
 	"github.com/zachwalton/devoid/pkg/errors"
+	"github.com/zachwalton/devoid/pkg/policy"
+	"github.com/zachwalton/devoid/pkg/runner"
+	"github.com/zachwalton/devoid/pkg/secrets"
+	"github.com/zachwalton/devoid/pkg/tui"
 )
 
 func HandleInitial(payload *brain.StagePayload, cfg *config.Config) error {
-  if payload.Meta.Name == "" {
-    return fmt.Errorf("%w: meta -> name was unset", errors.ErrRecoverable)
-  }
+	if payload.Meta.Name == "" {
+		return fmt.Errorf("%w: meta -> name was unset", errors.ErrRecoverable)
+	}
+	fromBlueprint, err := applyBlueprint(payload, cfg)
+	if err != nil {
+		return err
+	}
+	if err := classifyCommands(payload, cfg, fromBlueprint); err != nil {
+		return err
+	}
 	log.Info(
 		"completed validations and safety checks on project layout paths and bootstrap commands",
-    "stage",
-    payload.Meta.CurrentStage,
+		"stage",
+		payload.Meta.CurrentStage,
 	)
 	return nil
 }
+
+// ApplyInitial runs the bootstrap commands once the user has accepted the
+// initial stage. Blocked commands are never run, and commands that need
+// confirmation are prompted for individually unless interactive safety checks
+// are skipped.
+func ApplyInitial(ctx context.Context, payload *brain.StagePayload, cfg *config.Config) error {
+	if len(payload.Commands) == 0 {
+		return nil
+	}
+	r, err := runner.New(cfg.Sandbox)
+	if err != nil {
+		return err
+	}
+	for i := range payload.Commands {
+		command := &payload.Commands[i]
+		switch config.PolicyAction(command.Action) {
+		case config.PolicyBlock:
+			log.Warn("skipping command blocked by policy", "command", command.Command, "reason", command.Reason)
+			command.Status = brain.CommandBlocked
+			continue
+		case config.PolicyConfirm:
+			if !cfg.SkipInteractiveSafetyChecks {
+				choiceRun := fmt.Sprintf("Run `%s` (%s)", command.Command, command.Reason)
+				choiceSkip := "Skip this command, I'll run it myself if needed"
+				if tui.List([]string{choiceRun, choiceSkip}) != choiceRun {
+					log.Warn("skipping command by user request", "command", command.Command)
+					command.Status = brain.CommandSkipped
+					continue
+				}
+			}
+		}
+		if tui.Interrupted() {
+			return fmt.Errorf("interrupted before running %q", command.Command)
+		}
+		log.Info("running bootstrap command", "command", command.Command, "dir", cfg.ProjectPath)
+		res, err := r.Run(ctx, cfg.ProjectPath, command.Command)
+		if res != nil {
+			command.ExitCode = res.ExitCode
+			command.Sandboxed = res.Sandboxed
+			command.Output = res.Output
+			if res.Output != "" {
+				tui.Println(res.Output)
+			}
+		}
+		if err != nil {
+			command.Status = brain.CommandFailed
+			return err
+		}
+		command.Status = brain.CommandRan
+	}
+	return nil
+}
+
+// classifyCommands runs each bootstrap command through the safety policy.
+// Blocked commands proposed by the model are sent back to it; blocked
+// commands from a blueprint are kept so the user can see why they won't run.
+func classifyCommands(payload *brain.StagePayload, cfg *config.Config, fromBlueprint bool) error {
+	engine, err := policy.New(cfg.Policy)
+	if err != nil {
+		return err
+	}
+	scanner, err := secrets.New(cfg.Secrets)
+	if err != nil {
+		return err
+	}
+	payload.Commands = nil
+	payload.Findings = nil
+	for i, command := range payload.Bootstrap {
+		v := engine.Classify(command)
+		if v.Action == config.PolicyBlock && !fromBlueprint {
+			return fmt.Errorf(
+				"%w: bootstrap -> %d %q is not allowed because %s. Remove it or use a safer alternative",
+				errors.ErrRecoverable, i, command, v.Reason,
+			)
+		}
+		// Commands with secrets in them are redacted, which usually breaks
+		// them, so they always need confirmation at least.
+		if findings := scanner.Scan(fmt.Sprintf("bootstrap -> %d", i), command); len(findings) > 0 {
+			if scanner.Block() && !fromBlueprint {
+				return fmt.Errorf(
+					"%w: bootstrap -> %d contains what looks like a %s. Never put secrets in commands, read them from the environment instead",
+					errors.ErrRecoverable, i, findings[0].Description,
+				)
+			}
+			command = secrets.Redact(command, findings)
+			payload.Bootstrap[i] = command
+			payload.Findings = append(payload.Findings, findings...)
+			if v.Action == config.PolicyAllow {
+				v.Action = config.PolicyConfirm
+			}
+			v.Reason = fmt.Sprintf("%s, and contained a likely %s that was redacted", v.Reason, findings[0].Description)
+		}
+		payload.Commands = append(payload.Commands, brain.Command{
+			Command: command,
+			Action:  string(v.Action),
+			Reason:  v.Reason,
+		})
+	}
+	return nil
+}
+
+// applyBlueprint fills in the project from the selected blueprint, and returns
+// true if the bootstrap commands came from it.
+func applyBlueprint(payload *brain.StagePayload, cfg *config.Config) (bool, error) {
+	if payload.Meta.Blueprint == "" || payload.Meta.Blueprint == blueprints.Unset {
+		payload.Meta.Blueprint = blueprints.Unset
+		return false, nil
+	}
+	lib, err := blueprints.Load(cfg.BlueprintsDir)
+	if err != nil {
+		return false, err
+	}
+	bp, err := lib.Get(payload.Meta.Blueprint, payload.Meta.Name)
+	if goerrors.Is(err, errors.ErrUnknownBlueprint) {
+		return false, fmt.Errorf(
+			"%w: meta -> blueprint must be one of %s or 'unset', got %q",
+			errors.ErrRecoverable, strings.Join(lib.Names(), ", "), payload.Meta.Blueprint,
+		)
+	}
+	if goerrors.Is(err, errors.ErrInvalidName) {
+		return false, fmt.Errorf(
+			"%w: meta -> name must start with a letter or digit and only contain letters, digits, '.', '_', '/' and '-' when a blueprint is used, got %q",
+			errors.ErrRecoverable, payload.Meta.Name,
+		)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	for _, f := range []struct {
+		field *string
+		value string
+	}{
+		{&payload.Meta.Language, bp.Language},
+		{&payload.Meta.Framework, bp.Framework},
+		{&payload.Meta.Architecture, bp.Architecture},
+		{&payload.Meta.Test, bp.Test},
+	} {
+		if *f.field == "" || *f.field == "unset" {
+			*f.field = f.value
+		}
+	}
+	payload.Meta.TestCommand = bp.TestCommand
+	// Existing projects have already been bootstrapped.
+	if cfg.Existing {
+		return false, nil
+	}
+	payload.Bootstrap = bp.Bootstrap
+	return true, nil
+}
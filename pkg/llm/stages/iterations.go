@@ -0,0 +1,177 @@
+package stages
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/zachwalton/devoid/pkg/diff"
+	"github.com/zachwalton/devoid/pkg/tui"
+)
+
+// maxInlineValue is the longest value shown inline in a comparison. Longer
+// ones are shown in a code block.
+const maxInlineValue = 80
+
+// Iteration is a result of a stage that was shown to the user, kept so it can
+// be compared with the others and reverted to.
+type Iteration struct {
+	Number int
+	// Source is what produced the result, e.g. a change request.
+	Source   string
+	Response string
+}
+
+// source returns Source on a single line.
+func (it Iteration) source() string {
+	return strings.Join(strings.Fields(it.Source), " ")
+}
+
+func (it Iteration) label(latest bool) string {
+	source := it.source()
+	if r := []rune(source); len(r) > 60 {
+		source = string(r[:59]) + "…"
+	}
+	label := fmt.Sprintf("Iteration %d: %s", it.Number, source)
+	if latest {
+		label += " (current)"
+	}
+	return label
+}
+
+// CompareIterations lets the user pick two iterations of a stage and shows the
+// fields that changed between them, until they go back or choose to revert to
+// one of them. It returns the iteration to revert to, or false if they went
+// back.
+func CompareIterations(stage string, iterations []Iteration) (Iteration, bool, error) {
+	choiceBack := "Back"
+	choiceOther := "Compare other iterations"
+	latest := iterations[len(iterations)-1].Number
+	labels := map[string]Iteration{}
+	var all []string
+	// The latest iteration comes first, since it's what most comparisons
+	// are against.
+	for i := len(iterations) - 1; i >= 0; i-- {
+		l := iterations[i].label(iterations[i].Number == latest)
+		labels[l] = iterations[i]
+		all = append(all, l)
+	}
+
+	for {
+		first := tui.ListWithTitle("Compare which iteration?", append(all, choiceBack))
+		if first == "" || first == choiceBack {
+			return Iteration{}, false, nil
+		}
+		var rest []string
+		for _, l := range all {
+			if l != first {
+				rest = append(rest, l)
+			}
+		}
+		second := tui.ListWithTitle("With which iteration?", append(rest, choiceBack))
+		if second == "" {
+			return Iteration{}, false, nil
+		}
+		if second == choiceBack {
+			continue
+		}
+
+		a, b := labels[first], labels[second]
+		if a.Number > b.Number {
+			a, b = b, a
+		}
+		content, err := compareMarkdown(stage, a, b)
+		if err != nil {
+			return Iteration{}, false, err
+		}
+		tui.DiffView(fmt.Sprintf("Iteration %d → iteration %d", a.Number, b.Number), content)
+
+		var choices []string
+		for _, it := range []Iteration{a, b} {
+			if it.Number != latest {
+				choices = append(choices, fmt.Sprintf("Revert to iteration %d", it.Number))
+			}
+		}
+		choices = append(choices, choiceOther, choiceBack)
+		switch choice := tui.ListWithTitle("What do you want to do?", choices); choice {
+		case "", choiceBack:
+			return Iteration{}, false, nil
+		case choiceOther:
+			continue
+		case fmt.Sprintf("Revert to iteration %d", a.Number):
+			return a, true, nil
+		default:
+			return b, true, nil
+		}
+	}
+}
+
+// compareMarkdown describes the fields that changed from iteration a to b.
+func compareMarkdown(stage string, a, b Iteration) (string, error) {
+	var before, after any
+	if err := json.Unmarshal([]byte(a.Response), &before); err != nil {
+		return "", fmt.Errorf("could not parse iteration %d of the %s stage: %w", a.Number, stage, err)
+	}
+	if err := json.Unmarshal([]byte(b.Response), &after); err != nil {
+		return "", fmt.Errorf("could not parse iteration %d of the %s stage: %w", b.Number, stage, err)
+	}
+	changes := diff.Fields(before, after)
+
+	var md strings.Builder
+	fmt.Fprintf(&md, "# `%s` iterations %d and %d\n\n", stage, a.Number, b.Number)
+	fmt.Fprintf(&md, "* **Iteration %d:** %s\n* **Iteration %d:** %s\n\n", a.Number, a.source(), b.Number, b.source())
+	if len(changes) == 0 {
+		md.WriteString("_No fields changed._\n")
+		return md.String(), nil
+	}
+	if len(changes) == 1 {
+		md.WriteString("1 field changed.\n\n")
+	} else {
+		fmt.Fprintf(&md, "%d fields changed.\n\n", len(changes))
+	}
+	for _, c := range changes {
+		fmt.Fprintf(&md, "## `%s` (%s)\n\n", c.Label, c.Kind)
+		switch c.Kind {
+		case diff.Added:
+			md.WriteString(formatValue(c.After))
+		case diff.Removed:
+			md.WriteString(formatValue(c.Before))
+		default:
+			before, beforeOK := c.Before.(string)
+			after, afterOK := c.After.(string)
+			if beforeOK && afterOK && (strings.Contains(before, "\n") || strings.Contains(after, "\n")) {
+				md.WriteString(fence("diff", diff.Unified(fmt.Sprintf("iteration %d", a.Number), fmt.Sprintf("iteration %d", b.Number), before, after)))
+				break
+			}
+			before, after = formatValue(c.Before), formatValue(c.After)
+			if inline(before) && inline(after) {
+				fmt.Fprintf(&md, "* **Iteration %d:** %s* **Iteration %d:** %s", a.Number, before, b.Number, after)
+				break
+			}
+			fmt.Fprintf(&md, "**Iteration %d:**\n\n%s\n**Iteration %d:**\n\n%s", a.Number, before, b.Number, after)
+		}
+		md.WriteString("\n")
+	}
+	return md.String(), nil
+}
+
+// inline returns true if formatValue showed a value inline.
+func inline(formatted string) bool {
+	return strings.Count(formatted, "\n") == 1 && strings.HasPrefix(formatted, "`")
+}
+
+// formatValue shows a value inline if it's short, or in a code block.
+func formatValue(v any) string {
+	if s, ok := v.(string); ok && (strings.Contains(s, "\n") || len(s) > maxInlineValue) {
+		return fence("", s)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v\n", v)
+	}
+	if len(b) <= maxInlineValue && !strings.Contains(string(b), "`") {
+		return "`" + string(b) + "`\n"
+	}
+	b, _ = json.MarshalIndent(v, "", "  ")
+	return fence("json", string(b))
+}
@@ -0,0 +1,180 @@
+package stages
+
+import (
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/zachwalton/devoid/pkg/brain"
+	"github.com/zachwalton/devoid/pkg/brain/schema"
+	"github.com/zachwalton/devoid/pkg/errors"
+	"github.com/zachwalton/devoid/pkg/tui"
+)
+
+// Browsable returns true if payload has a planned layout to browse.
+func Browsable(payload *brain.StagePayload) bool {
+	return len(payload.AST) > 0
+}
+
+// BrowseLayout shows the planned files in a tree, along with how far along
+// each one is. In the ast stage files can also be added, removed and renamed;
+// the edited response is validated against the stage's schema and then by
+// check, and the tree is shown again with any problems. It returns the edited
+// response, or false if the user closed the tree without changing anything.
+func BrowseLayout(stage, response, stageSchema string, payload *brain.StagePayload, accepted map[string]string, check func(response string) error) (string, bool, error) {
+	editable := stage == "ast"
+	original := treeNodes(stage, payload, accepted)
+	nodes := original
+	title := "Planned files"
+	if editable {
+		title = "Planned files (editable)"
+	}
+
+	problem := ""
+	for {
+		edited, ok := tui.Tree(title, problem, nodes, editable)
+		if !ok || !editable {
+			return "", false, nil
+		}
+		nodes, problem = edited, ""
+
+		ast := make([]brain.FileNode, len(nodes))
+		for i, n := range nodes {
+			ast[i] = brain.FileNode{Path: n.Path, Purpose: n.Purpose, Action: n.Action, DependsOn: n.DependsOn}
+		}
+		added, removed, renamed := layoutChanges(payload.AST, ast)
+		if sameValue(original, nodes) {
+			log.Info("nothing was changed", "stage", stage)
+			return "", false, nil
+		}
+
+		var doc map[string]any
+		if err := json.Unmarshal([]byte(response), &doc); err != nil {
+			return "", false, fmt.Errorf("could not parse the %s stage's response: %w", stage, err)
+		}
+		// Round-trip the nodes so the document only holds JSON types, which
+		// is what the schema validates.
+		var value any
+		b, err := json.Marshal(ast)
+		if err != nil {
+			return "", false, err
+		}
+		json.Unmarshal(b, &value)
+		schema.Set(doc, "/ast", value)
+
+		root, err := schema.Parse(stageSchema)
+		if err != nil {
+			return "", false, err
+		}
+		var problems []string
+		for _, v := range root.Validate(doc) {
+			if strings.HasPrefix(v.Pointer, "/ast") {
+				problems = append(problems, v.String())
+			}
+		}
+		for _, n := range ast {
+			if strings.TrimSpace(n.Purpose) == "" {
+				problems = append(problems, fmt.Sprintf("%s: purpose can't be empty", n.Path))
+			}
+		}
+		if len(problems) > 0 {
+			problem = strings.Join(problems, "\n")
+			continue
+		}
+
+		description := "Edited the planned files directly"
+		if len(added) > 0 {
+			description += "; added " + strings.Join(added, ", ")
+		}
+		if len(removed) > 0 {
+			description += "; removed " + strings.Join(removed, ", ")
+		}
+		if len(renamed) > 0 {
+			description += "; renamed " + strings.Join(renamed, ", ")
+		}
+		schema.Set(doc, "/state_machine/description", description)
+		b, err = json.Marshal(doc)
+		if err != nil {
+			return "", false, err
+		}
+		if err := check(string(b)); err != nil {
+			if !goerrors.Is(err, errors.ErrRecoverable) {
+				return "", false, err
+			}
+			problem = strings.TrimPrefix(err.Error(), errors.ErrRecoverable.Error()+": ")
+			continue
+		}
+		return string(b), true, nil
+	}
+}
+
+// treeNodes returns the planned files, with whether each one has been
+// generated and reviewed yet.
+func treeNodes(stage string, payload *brain.StagePayload, accepted map[string]string) []tui.TreeNode {
+	generated := map[string]bool{}
+	for _, f := range payload.Files {
+		generated[f.Path] = true
+	}
+	problems := map[string]int{}
+	for _, d := range payload.Diagnostics {
+		problems[d.Path]++
+	}
+
+	var nodes []tui.TreeNode
+	for _, n := range payload.AST {
+		status := "not generated yet"
+		switch _, ok := accepted[n.Path]; {
+		case ok:
+			status = "accepted"
+		case generated[n.Path]:
+			status = "generated"
+		case stage == "code":
+			status = "missing from the generated files"
+		}
+		if problems[n.Path] > 0 {
+			status += fmt.Sprintf(", %d problem(s)", problems[n.Path])
+		}
+		nodes = append(nodes, tui.TreeNode{
+			Path:      n.Path,
+			Purpose:   n.Purpose,
+			Action:    n.Action,
+			DependsOn: append([]string{}, n.DependsOn...),
+			Status:    status,
+		})
+	}
+	return nodes
+}
+
+// layoutChanges returns the paths added to and removed from a layout. A file
+// that was removed and added again with the same purpose is reported as
+// renamed instead.
+func layoutChanges(before, after []brain.FileNode) (added, removed, renamed []string) {
+	find := func(nodes []brain.FileNode, p string) int {
+		return slices.IndexFunc(nodes, func(n brain.FileNode) bool { return n.Path == p })
+	}
+	var gone []brain.FileNode
+	for _, n := range before {
+		if find(after, n.Path) < 0 {
+			gone = append(gone, n)
+		}
+	}
+	for _, n := range after {
+		if find(before, n.Path) >= 0 {
+			continue
+		}
+		i := slices.IndexFunc(gone, func(g brain.FileNode) bool { return g.Purpose == n.Purpose })
+		if i < 0 {
+			added = append(added, n.Path)
+			continue
+		}
+		renamed = append(renamed, gone[i].Path+" to "+n.Path)
+		gone = slices.Delete(gone, i, i+1)
+	}
+	for _, n := range gone {
+		removed = append(removed, n.Path)
+	}
+	return added, removed, renamed
+}
@@ -0,0 +1,96 @@
+package stages
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/zachwalton/devoid/pkg/brain"
+	"github.com/zachwalton/devoid/pkg/config"
+	"github.com/zachwalton/devoid/pkg/tui"
+)
+
+// skipAnswer is the option for skipping choice and yes/no questions. Text
+// questions are skipped by leaving them empty, or by entering it if the model
+// suggested an answer.
+const skipAnswer = "(skip)"
+
+// AnswerQuestions asks the model's choice and yes/no questions in a single
+// form, where each one starts at the model's suggested answer and can be
+// skipped. Text questions, including choices without options, are then asked
+// one at a time with Ask, so answers can span several lines and use the
+// input history and snippets, and an empty answer accepts the suggested one.
+// It returns the answers, or false if the user cancelled.
+func AnswerQuestions(questions []brain.Question, cfg *config.Config) ([]brain.Answer, bool) {
+	answers := make([]brain.Answer, len(questions))
+	var fields []tui.Field
+	var text []int
+	for i, q := range questions {
+		f := tui.Field{Key: strconv.Itoa(i), Label: q.Question, Value: q.Default}
+		switch {
+		case q.Type == brain.QuestionChoice && len(q.Options) > 0:
+			f.Options = append([]string{skipAnswer}, q.Options...)
+		case q.Type == brain.QuestionYesNo:
+			f.Options = []string{skipAnswer, "yes", "no"}
+			f.Value = strings.ToLower(f.Value)
+		default:
+			text = append(text, i)
+			continue
+		}
+		if !slices.Contains(f.Options, f.Value) {
+			f.Value = skipAnswer
+		}
+		if q.Default != "" {
+			f.Description = "Suggested: " + q.Default
+		}
+		fields = append(fields, f)
+	}
+
+	if len(fields) > 0 {
+		values, ok := tui.Form("Answer the model's questions", "", fields)
+		if !ok {
+			return nil, false
+		}
+		for _, f := range values {
+			i, _ := strconv.Atoi(f.Key)
+			answers[i] = answer(questions[i], f.Value)
+		}
+	}
+	for _, i := range text {
+		prompt := questions[i].Question + "\n\nLeave empty to skip."
+		if questions[i].Default != "" {
+			prompt = fmt.Sprintf("%s\n\nSuggested: %s\n\nLeave empty to accept the suggestion, or enter %s to skip.", questions[i].Question, questions[i].Default, skipAnswer)
+		}
+		value := Ask(prompt, cfg)
+		if tui.Interrupted() {
+			return nil, false
+		}
+		answers[i] = textAnswer(questions[i], value)
+	}
+	return answers, true
+}
+
+// answer records value as the answer to q, or q as skipped.
+func answer(q brain.Question, value string) brain.Answer {
+	if v := strings.TrimSpace(value); v == "" || v == skipAnswer {
+		return brain.Answer{Question: q.Question, Skipped: true}
+	}
+	return brain.Answer{Question: q.Question, Answer: value}
+}
+
+// textAnswer records value as the answer to the text question q, accepting its
+// suggested answer if value is empty.
+func textAnswer(q brain.Question, value string) brain.Answer {
+	if strings.TrimSpace(value) == "" {
+		value = q.Default
+	}
+	return answer(q, value)
+}
+
+// AnswersPrompt tells the model the user's answers.
+func AnswersPrompt(answers []brain.Answer) string {
+	b, _ := json.MarshalIndent(answers, "", "  ")
+	return fmt.Sprintf("Here are my answers to your questions. I skipped the ones marked as skipped, so choose sensible values for those yourself:\n%s\n", b)
+}
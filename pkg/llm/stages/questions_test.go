@@ -0,0 +1,30 @@
+package stages
+
+import (
+	"testing"
+
+	"github.com/zachwalton/devoid/pkg/brain"
+)
+
+func TestTextAnswer(t *testing.T) {
+	suggested := brain.Question{Question: "Which port?", Type: brain.QuestionText, Default: "8080"}
+	open := brain.Question{Question: "Anything else?", Type: brain.QuestionText}
+	tests := []struct {
+		name     string
+		question brain.Question
+		value    string
+		want     brain.Answer
+	}{
+		{name: "answer", question: suggested, value: "9090", want: brain.Answer{Question: "Which port?", Answer: "9090"}},
+		{name: "empty accepts the suggestion", question: suggested, value: " \n", want: brain.Answer{Question: "Which port?", Answer: "8080"}},
+		{name: "skip with a suggestion", question: suggested, value: skipAnswer + "\n", want: brain.Answer{Question: "Which port?", Skipped: true}},
+		{name: "empty without a suggestion", question: open, want: brain.Answer{Question: "Anything else?", Skipped: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := textAnswer(tt.question, tt.value); got != tt.want {
+				t.Errorf("textAnswer(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
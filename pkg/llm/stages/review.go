@@ -0,0 +1,217 @@
+package stages
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/zachwalton/devoid/pkg/brain"
+	"github.com/zachwalton/devoid/pkg/config"
+	"github.com/zachwalton/devoid/pkg/diff"
+	"github.com/zachwalton/devoid/pkg/secrets"
+	"github.com/zachwalton/devoid/pkg/tui"
+	"github.com/zachwalton/devoid/pkg/validate"
+	"github.com/zachwalton/devoid/pkg/workspace"
+)
+
+const (
+	reviewPending  = "pending"
+	reviewAccepted = "accepted"
+	reviewRejected = "rejected"
+	reviewChanges  = "changes requested"
+)
+
+type fileReview struct {
+	file    brain.File
+	old     string
+	existed bool
+	stats   diff.Stats
+	status  string
+	note    string
+	// findings are likely secrets that were redacted from the file.
+	findings []secrets.Finding
+	// diagnostics are static check problems the model didn't fix.
+	diagnostics []validate.Diagnostic
+}
+
+// ReviewFiles lets the user accept, reject or request changes to each
+// generated file before anything is written. Accepted files are recorded in
+// accepted so they survive regeneration. If any file wasn't accepted, the
+// returned feedback should be sent back to the model. It returns false if the
+// user cancelled the review.
+func ReviewFiles(payload *brain.StagePayload, cfg *config.Config, accepted map[string]string) (string, bool, error) {
+	ws, err := workspace.New(cfg.ProjectPath)
+	if err != nil {
+		return "", false, err
+	}
+
+	var reviews []*fileReview
+	for _, file := range payload.Files {
+		r := &fileReview{file: file, status: reviewPending}
+		for _, f := range payload.Findings {
+			if f.Path == file.Path {
+				r.findings = append(r.findings, f)
+			}
+		}
+		for _, d := range payload.Diagnostics {
+			if d.Path == file.Path {
+				r.diagnostics = append(r.diagnostics, d)
+			}
+		}
+		if content, err := ws.Read(file.Path); err == nil {
+			r.old, r.existed = string(content), true
+		}
+		r.stats = diff.Stat(r.old, file.Content)
+		if content, ok := accepted[file.Path]; ok && content == file.Content {
+			r.status = reviewAccepted
+		}
+		reviews = append(reviews, r)
+	}
+
+	choiceAcceptAll := "Accept all pending files"
+	choiceFinish := "Finish the review"
+	for {
+		pending := 0
+		var choices []string
+		byChoice := map[string]*fileReview{}
+		for _, r := range reviews {
+			if r.status == reviewPending {
+				pending++
+			}
+			c := fmt.Sprintf("[%s] %s (+%d -%d)", r.status, r.file.Path, r.stats.Added, r.stats.Removed)
+			if len(r.findings) > 0 {
+				c += fmt.Sprintf(" ⚠️ %d secret(s) redacted", len(r.findings))
+			}
+			if len(r.diagnostics) > 0 {
+				c += fmt.Sprintf(" ❌ %d problem(s)", len(r.diagnostics))
+			}
+			choices = append(choices, c)
+			byChoice[c] = r
+		}
+		if pending > 0 {
+			choices = append(choices, choiceAcceptAll)
+		} else {
+			choices = append(choices, choiceFinish)
+		}
+
+		choice := tui.ListWithTitle("Select a file to review it", choices)
+		switch choice {
+		case "":
+			return "", false, nil
+		case choiceAcceptAll:
+			for _, r := range reviews {
+				if r.status == reviewPending {
+					r.status = reviewAccepted
+				}
+			}
+			continue
+		case choiceFinish:
+			return feedback(reviews, accepted), true, nil
+		}
+		reviewFile(byChoice[choice], cfg)
+	}
+}
+
+func reviewFile(r *fileReview, cfg *config.Config) {
+	tui.DiffView(r.file.Path, r.markdown())
+
+	choiceAccept := "Accept this file"
+	choiceReject := "Reject this file"
+	choiceChanges := "Request changes to this file"
+	choiceBack := "Back to the file list"
+	switch tui.ListWithTitle(fmt.Sprintf("What do you want to do with %s?", r.file.Path), []string{choiceAccept, choiceReject, choiceChanges, choiceBack}) {
+	case choiceAccept:
+		r.status, r.note = reviewAccepted, ""
+	case choiceReject:
+		r.status = reviewRejected
+		r.note = tui.Input("Why are you rejecting this file? (optional)")
+	case choiceChanges:
+		for {
+			r.note = Ask("What should change in this file?", cfg)
+			if r.note != "" || tui.Interrupted() {
+				break
+			}
+			log.Warn("You didn't enter any text! Try again...")
+		}
+		r.status = reviewChanges
+	}
+}
+
+func (r *fileReview) markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# `%s`\n\n", r.file.Path)
+	if len(r.findings) > 0 {
+		b.WriteString("> **Warning:** likely secrets were redacted from this file. Check that it still works without them.\n\n")
+		for _, f := range r.findings {
+			fmt.Fprintf(&b, "* line %d: %s, replaced with `%s`\n", f.Line, f.Description, secrets.Marker(f.Rule))
+		}
+		b.WriteString("\n")
+	}
+	if len(r.diagnostics) > 0 {
+		b.WriteString("> **Warning:** static checks found problems the model didn't fix.\n\n")
+		for _, d := range r.diagnostics {
+			fmt.Fprintf(&b, "* line %d (%s): %s\n", d.Line, d.Tool, d.Message)
+		}
+		b.WriteString("\n")
+	}
+	if !r.existed {
+		fmt.Fprintf(&b, "**New file** (%d lines)\n\n", r.stats.Added)
+		b.WriteString(fence(strings.TrimPrefix(filepath.Ext(r.file.Path), "."), r.file.Content))
+		return b.String()
+	}
+	fmt.Fprintf(&b, "**Modified file** (+%d -%d)\n\n", r.stats.Added, r.stats.Removed)
+	d := diff.Unified("a/"+r.file.Path, "b/"+r.file.Path, r.old, r.file.Content)
+	if d == "" {
+		b.WriteString("_No changes._\n")
+		return b.String()
+	}
+	b.WriteString(fence("diff", d))
+	return b.String()
+}
+
+func feedback(reviews []*fileReview, accepted map[string]string) string {
+	var keep []string
+	var redo strings.Builder
+	for _, r := range reviews {
+		switch r.status {
+		case reviewAccepted:
+			accepted[r.file.Path] = r.file.Content
+			keep = append(keep, r.file.Path)
+		case reviewRejected, reviewChanges:
+			delete(accepted, r.file.Path)
+			note := r.note
+			if note == "" {
+				note = "no reason given, try a different approach"
+			}
+			fmt.Fprintf(&redo, "- %s (%s): %s\n", r.file.Path, r.status, note)
+		}
+	}
+	if redo.Len() == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("I reviewed the generated files.")
+	if len(keep) > 0 {
+		fmt.Fprintf(&b, " Keep the content of these accepted files exactly as it is: %s.", strings.Join(keep, ", "))
+	}
+	b.WriteString(" Regenerate the following files, addressing my notes:\n")
+	b.WriteString(redo.String())
+	return b.String()
+}
+
+// fence wraps content in a Markdown code fence that's longer than any run of
+// backticks inside it.
+func fence(lang, content string) string {
+	longest, run := 0, 0
+	for _, c := range content {
+		if c == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	f := strings.Repeat("`", max(3, longest+1))
+	return fmt.Sprintf("%s%s\n%s\n%s\n", f, lang, strings.TrimSuffix(content, "\n"), f)
+}
@@ -0,0 +1,209 @@
+package stages
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/zachwalton/devoid/pkg/brain"
+	"github.com/zachwalton/devoid/pkg/brain/schema"
+	"github.com/zachwalton/devoid/pkg/config"
+	"github.com/zachwalton/devoid/pkg/errors"
+)
+
+type (
+	// Rule is a semantic check on a stage's result, for mistakes the schema
+	// can't catch. Rules are declared per stage in the stage registry.
+	Rule struct {
+		Name  string
+		Check func(payload *brain.StagePayload, rc RuleContext) []schema.Violation
+	}
+
+	// RuleContext is what rules know about the session beyond the result.
+	RuleContext struct {
+		// Stages are the names of the registered stages.
+		Stages []string
+		// Final is true if the stage being checked is the last one.
+		Final bool
+		// CustomFields are the custom metadata fields declared in the
+		// config.
+		CustomFields []config.MetaField
+	}
+)
+
+var (
+	// forbiddenValues are placeholders the model uses instead of leaving a
+	// field unset.
+	forbiddenValues = []string{"none", "n/a", "null"}
+
+	// frameworkLanguages maps well-known frameworks to the languages they're
+	// written in. Frameworks that aren't listed are allowed with any
+	// language.
+	frameworkLanguages = map[string][]string{
+		"django":        {"python"},
+		"flask":         {"python"},
+		"fastapi":       {"python"},
+		"click":         {"python"},
+		"rails":         {"ruby"},
+		"ruby on rails": {"ruby"},
+		"sinatra":       {"ruby"},
+		"express":       {"javascript", "typescript"},
+		"nestjs":        {"javascript", "typescript"},
+		"next.js":       {"javascript", "typescript"},
+		"react":         {"javascript", "typescript"},
+		"vue":           {"javascript", "typescript"},
+		"angular":       {"javascript", "typescript"},
+		"spring":        {"java", "kotlin"},
+		"spring boot":   {"java", "kotlin"},
+		"gin":           {"go", "golang"},
+		"echo":          {"go", "golang"},
+		"fiber":         {"go", "golang"},
+		"cobra":         {"go", "golang"},
+		"laravel":       {"php"},
+		"symfony":       {"php"},
+		"actix":         {"rust"},
+		"axum":          {"rust"},
+		"rocket":        {"rust"},
+		"clap":          {"rust"},
+		"asp.net":       {"c#", "f#"},
+		"phoenix":       {"elixir"},
+	}
+
+	// proceedQuestion matches questions asking for permission to continue,
+	// which the menu already covers.
+	proceedQuestion = regexp.MustCompile(`(?i)\b(should|shall|can|may) (i|we) (proceed|continue|go ahead|start)\b|\b(do you want|would you like) (me|us) to (proceed|continue|go ahead)\b|\bready to (proceed|continue)\b`)
+)
+
+var (
+	// NoForbiddenValues rejects "none" and similar placeholders in any field
+	// except file contents, and custom fields whose configured options or
+	// default include them.
+	NoForbiddenValues = Rule{
+		Name: "no forbidden values",
+		Check: func(payload *brain.StagePayload, rc RuleContext) []schema.Violation {
+			allowed := map[string][]string{}
+			for _, f := range rc.CustomFields {
+				allowed[schema.Pointer("/meta/custom", f.Name)] = append(slices.Clone(f.Options), f.Default)
+			}
+			b, err := json.Marshal(payload)
+			if err != nil {
+				return nil
+			}
+			var doc map[string]any
+			json.Unmarshal(b, &doc)
+			delete(doc, "files")
+			var violations []schema.Violation
+			walkStrings("", doc, func(pointer, s string) {
+				value := strings.ToLower(strings.TrimSpace(s))
+				if !slices.Contains(forbiddenValues, value) || slices.ContainsFunc(allowed[pointer], func(o string) bool { return strings.EqualFold(strings.TrimSpace(o), value) }) {
+					return
+				}
+				violations = append(violations, schema.Violation{Pointer: pointer, Message: fmt.Sprintf("%q is never a valid value, use the schema's default or \"unset\" instead", s)})
+			})
+			return violations
+		},
+	}
+
+	// KnownFrameworks rejects well-known frameworks paired with a language
+	// they aren't written in, e.g. Django with Go.
+	KnownFrameworks = Rule{
+		Name: "known language and framework pairs",
+		Check: func(payload *brain.StagePayload, _ RuleContext) []schema.Violation {
+			languages := splitList(payload.Meta.Language)
+			var violations []schema.Violation
+			for _, framework := range splitList(payload.Meta.Framework) {
+				want, ok := frameworkLanguages[framework]
+				if !ok || slices.ContainsFunc(languages, func(l string) bool { return slices.Contains(want, l) }) {
+					continue
+				}
+				violations = append(violations, schema.Violation{
+					Pointer: "/meta/framework",
+					Message: fmt.Sprintf("%s is a %s framework, but the language is %q. Change the framework or the language so they match", framework, strings.Join(want, " or "), payload.Meta.Language),
+				})
+			}
+			return violations
+		},
+	}
+
+	// RegisteredNext requires state_machine.next to name a stage that exists,
+	// except in the final stage.
+	RegisteredNext = Rule{
+		Name: "next is a registered stage",
+		Check: func(payload *brain.StagePayload, rc RuleContext) []schema.Violation {
+			if rc.Final || slices.Contains(rc.Stages, payload.StateMachine.Next) {
+				return nil
+			}
+			return []schema.Violation{{
+				Pointer: "/state_machine/next",
+				Message: fmt.Sprintf("must be one of %s, got %q", strings.Join(rc.Stages, ", "), payload.StateMachine.Next),
+			}}
+		},
+	}
+
+	// NoProceedQuestions rejects questions like "Should I proceed?", since
+	// the user already chooses whether to move ahead.
+	NoProceedQuestions = Rule{
+		Name: "no questions about proceeding",
+		Check: func(payload *brain.StagePayload, _ RuleContext) []schema.Violation {
+			var violations []schema.Violation
+			for i, q := range payload.StateMachine.Questions {
+				if proceedQuestion.MatchString(q.Question) {
+					violations = append(violations, schema.Violation{
+						Pointer: schema.Pointer("/state_machine/questions", fmt.Sprint(i)),
+						Message: fmt.Sprintf("%q asks whether to proceed, which the user decides separately. Remove it, and only ask about specific characteristics of the codebase", q.Question),
+					})
+				}
+			}
+			return violations
+		},
+	}
+)
+
+// CheckRules checks payload against every rule, returning an ErrRecoverable
+// listing all of the violations so the model can fix them at once.
+func CheckRules(rules []Rule, payload *brain.StagePayload, rc RuleContext) error {
+	var lines []string
+	for _, r := range rules {
+		for _, v := range r.Check(payload, rc) {
+			lines = append(lines, "- "+v.String())
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: the response breaks these rules:\n%s", errors.ErrRecoverable, strings.Join(lines, "\n"))
+}
+
+// walkStrings calls fn with every string in doc and its JSON pointer.
+func walkStrings(pointer string, doc any, fn func(pointer, s string)) {
+	switch v := doc.(type) {
+	case string:
+		fn(pointer, v)
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			walkStrings(schema.Pointer(pointer, k), v[k], fn)
+		}
+	case []any:
+		for i, e := range v {
+			walkStrings(schema.Pointer(pointer, fmt.Sprint(i)), e, fn)
+		}
+	}
+}
+
+// splitList splits a comma-delimited field into lowercase values.
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,134 @@
+package stages
+
+import (
+	goerrors "errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/zachwalton/devoid/pkg/brain"
+	"github.com/zachwalton/devoid/pkg/config"
+	"github.com/zachwalton/devoid/pkg/errors"
+)
+
+func TestRules(t *testing.T) {
+	rc := RuleContext{Stages: []string{"initial", "ast", "code"}}
+	tests := []struct {
+		name    string
+		rule    Rule
+		payload brain.StagePayload
+		rc      RuleContext
+		want    []string
+	}{
+		{
+			name:    "forbidden values",
+			rule:    NoForbiddenValues,
+			payload: brain.StagePayload{Meta: brain.MetaPayload{Test: "None", Architecture: " n/a "}, AST: []brain.FileNode{{Path: "main.go", Purpose: "null"}}},
+			want:    []string{"/ast/0/purpose", "/meta/architecture", "/meta/test"},
+		},
+		{
+			name:    "forbidden values in file contents",
+			rule:    NoForbiddenValues,
+			payload: brain.StagePayload{Files: []brain.File{{Path: "x.txt", Content: "none"}}},
+		},
+		{
+			name:    "forbidden values inside other values",
+			rule:    NoForbiddenValues,
+			payload: brain.StagePayload{Meta: brain.MetaPayload{Description: "none of the above"}},
+		},
+		{
+			name:    "framework for another language",
+			rule:    KnownFrameworks,
+			payload: brain.StagePayload{Meta: brain.MetaPayload{Language: "go", Framework: "Django"}},
+			want:    []string{"/meta/framework"},
+		},
+		{
+			name:    "framework for one of the languages",
+			rule:    KnownFrameworks,
+			payload: brain.StagePayload{Meta: brain.MetaPayload{Language: "Python, TypeScript", Framework: "django, react"}},
+		},
+		{
+			name:    "unknown framework",
+			rule:    KnownFrameworks,
+			payload: brain.StagePayload{Meta: brain.MetaPayload{Language: "go", Framework: "chi"}},
+		},
+		{
+			name:    "registered next stage",
+			rule:    RegisteredNext,
+			payload: brain.StagePayload{StateMachine: brain.StateMachinePayload{Next: "ast"}},
+			rc:      rc,
+		},
+		{
+			name:    "unregistered next stage",
+			rule:    RegisteredNext,
+			payload: brain.StagePayload{StateMachine: brain.StateMachinePayload{Next: "deploy"}},
+			rc:      rc,
+			want:    []string{"/state_machine/next"},
+		},
+		{
+			name:    "final stage",
+			rule:    RegisteredNext,
+			payload: brain.StagePayload{StateMachine: brain.StateMachinePayload{Next: "done"}},
+			rc:      RuleContext{Stages: rc.Stages, Final: true},
+		},
+		{
+			name: "questions about proceeding",
+			rule: NoProceedQuestions,
+			payload: brain.StagePayload{StateMachine: brain.StateMachinePayload{Questions: []brain.Question{
+				{Question: "Which database should the service use?"},
+				{Question: "Should I proceed with this plan?"},
+				{Question: "Would you like me to continue?"},
+			}}},
+			want: []string{"/state_machine/questions/1", "/state_machine/questions/2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, v := range tt.rule.Check(&tt.payload, tt.rc) {
+				got = append(got, v.Pointer)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Check() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNoForbiddenValuesCustomFields(t *testing.T) {
+	rc := RuleContext{CustomFields: []config.MetaField{
+		{Name: "team", Options: []string{"platform", "None"}},
+		{Name: "owner", Default: "n/a"},
+		{Name: "tier"},
+	}}
+	payload := &brain.StagePayload{Meta: brain.MetaPayload{
+		Test:   "none",
+		Custom: map[string]string{"team": "none", "owner": "N/A", "tier": "none", "other": "null"},
+	}}
+	var got []string
+	for _, v := range NoForbiddenValues.Check(payload, rc) {
+		got = append(got, v.Pointer)
+	}
+	slices.Sort(got)
+	want := []string{"/meta/custom/other", "/meta/custom/tier", "/meta/test"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Check() = %q, want %q", got, want)
+	}
+}
+
+func TestCheckRules(t *testing.T) {
+	payload := &brain.StagePayload{Meta: brain.MetaPayload{Test: "none", Language: "go", Framework: "rails"}}
+	if err := CheckRules([]Rule{RegisteredNext}, payload, RuleContext{Final: true}); err != nil {
+		t.Errorf("CheckRules() error = %v, want nil", err)
+	}
+	err := CheckRules([]Rule{NoForbiddenValues, KnownFrameworks}, payload, RuleContext{})
+	if !goerrors.Is(err, errors.ErrRecoverable) {
+		t.Fatalf("CheckRules() error = %v, want %v", err, errors.ErrRecoverable)
+	}
+	for _, want := range []string{"/meta/test", "/meta/framework"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("CheckRules() error = %v, want it to mention %s", err, want)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package policy
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/zachwalton/devoid/pkg/config"
+	"github.com/zachwalton/devoid/pkg/errors"
+)
+
+var (
+	builtins = []config.PolicyRule{
+		{
+			Name:        "privilege-escalation",
+			Action:      config.PolicyBlock,
+			Executables: []string{"sudo", "su", "doas", "pkexec"},
+			Reason:      "runs with elevated privileges",
+		},
+		{
+			Name:    "remote-code-execution",
+			Action:  config.PolicyBlock,
+			Pattern: `\b(curl|wget)\b[^|]*\|\s*(sudo\s+)?((ba|z|da|k|fi)?sh|python[0-9.]*|perl|ruby|node|deno|bun|php|lua)\b`,
+			Reason:  "downloads and executes remote code",
+		},
+		{
+			Name:        "delete-root-or-home",
+			Action:      config.PolicyBlock,
+			Executables: []string{"rm"},
+			// The recursive flag and the target can come in either order.
+			Args:   `(^|\s)` + recursive + `\s(.*\s)?` + outsideTarget + `|(^|\s)` + outsideTarget + `(.*\s)?` + recursive + `(\s|$)`,
+			Reason: "recursively deletes an absolute path, the home directory or files outside the project",
+		},
+		{
+			Name:        "write-outside-project",
+			Action:      config.PolicyBlock,
+			Executables: []string{"mkdir", "touch"},
+			Args:        `(^|\s)` + outsidePath,
+			Reason:      "creates files at an absolute path, in the home directory or outside the project",
+		},
+		{
+			Name:        "disk-destruction",
+			Action:      config.PolicyBlock,
+			Executables: []string{"mkfs", "fdisk", "parted", "wipefs", "shred"},
+			Reason:      "can destroy disks or filesystems",
+		},
+		{
+			Name:        "raw-device-write",
+			Action:      config.PolicyBlock,
+			Executables: []string{"dd"},
+			Args:        `\bof=/dev/`,
+			Reason:      "writes directly to a device",
+		},
+		{
+			Name:    "fork-bomb",
+			Action:  config.PolicyBlock,
+			Pattern: `:\(\)\s*\{\s*:\s*\|\s*:\s*&\s*\}\s*;\s*:`,
+			Reason:  "is a fork bomb",
+		},
+		{
+			Name:        "recursive-permissions",
+			Action:      config.PolicyBlock,
+			Executables: []string{"chmod", "chown"},
+			Args:        `-[a-zA-Z]*R[a-zA-Z]*\s+(.*\s)?(/|~|\$HOME)(\s|$)`,
+			Reason:      "recursively changes permissions outside the project",
+		},
+		{
+			Name:        "network-access",
+			Action:      config.PolicyConfirm,
+			Executables: []string{"curl", "wget", "nc", "ncat", "netcat", "ssh", "scp", "sftp", "rsync", "ftp", "telnet"},
+			Reason:      "accesses the network",
+		},
+		{
+			Name:        "delete-files",
+			Action:      config.PolicyConfirm,
+			Executables: []string{"rm", "rmdir", "unlink"},
+			Reason:      "deletes files",
+		},
+		{
+			Name:        "system-packages",
+			Action:      config.PolicyConfirm,
+			Executables: []string{"apt", "apt-get", "yum", "dnf", "pacman", "brew", "apk", "snap"},
+			Reason:      "installs or removes system packages",
+		},
+		{
+			Name:        "inline-code",
+			Action:      config.PolicyConfirm,
+			Executables: []string{"python", "python3", "node", "deno", "bun", "perl", "ruby", "php"},
+			Args:        `^(-\S+\s+)*(-[a-zA-Z]*[ce]|-p|--eval|--print)(\s|$)`,
+			Reason:      "runs inline code, which can't be inspected ahead of time",
+		},
+		{
+			Name:        "toolchains",
+			Action:      config.PolicyAllow,
+			Executables: []string{"go", "gofmt", "npm", "yarn", "pnpm", "pip", "pip3", "poetry", "uv", "cargo", "rustup", "bundle", "gem", "mvn", "gradle", "dotnet", "mkdir", "touch"},
+			Reason:      "is a common project toolchain command",
+		},
+		// Interpreters and git can run arbitrary code, e.g. a downloaded
+		// package, a script or a configured pager, so only well-known
+		// subcommands are allowed.
+		{
+			Name:        "python-modules",
+			Action:      config.PolicyAllow,
+			Executables: []string{"python", "python3"},
+			Args:        `^-m\s+(venv|pip|pytest|unittest)(\s|$)`,
+			Reason:      "runs a standard Python module",
+		},
+		{
+			Name:        "git-local",
+			Action:      config.PolicyAllow,
+			Executables: []string{"git"},
+			Args:        `^(init|status|add)(\s|$)`,
+			Reason:      "only changes the project's repository",
+		},
+	}
+
+	// recursive matches rm's recursive flag, and outsidePath the start of an
+	// argument that's an absolute path, in the home directory or above the
+	// working directory, optionally quoted. outsideTarget also matches a bare
+	// glob, which deletes everything in the working directory.
+	recursive     = `(-[a-zA-Z]*[rR][a-zA-Z]*|--recursive)`
+	outsidePath   = `["']?(/|~|\$HOME\b|\$\{HOME\}|(\S*/)?\.\.(["']?(\s|$)|/))`
+	outsideTarget = `(` + outsidePath + `|["']?\*["']?(\s|$))`
+
+	harmlessRe     = regexp.MustCompile(`\d*>>?\s*/dev/null|\d*>&\d+`)
+	separatorRe    = regexp.MustCompile(`&&|\|\||[;|&\n]`)
+	substitutionRe = regexp.MustCompile("\\$\\(|`")
+	redirectRe     = regexp.MustCompile(`>\s*` + outsidePath)
+	envAssignRe    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+)
+
+type (
+	Engine struct {
+		defaultAction config.PolicyAction
+		rules         []rule
+	}
+
+	// Verdict is the classification of a single command.
+	Verdict struct {
+		Command string
+		Action  config.PolicyAction
+		Rule    string
+		Reason  string
+	}
+
+	rule struct {
+		config.PolicyRule
+		executables map[string]bool
+		args        *regexp.Regexp
+		pattern     *regexp.Regexp
+	}
+)
+
+// New compiles the configured rules, followed by the built-in rules unless
+// they're disabled.
+func New(cfg config.Policy) (*Engine, error) {
+	e := &Engine{defaultAction: cfg.Default}
+	if e.defaultAction == "" {
+		e.defaultAction = config.PolicyConfirm
+	}
+	if err := validAction(e.defaultAction); err != nil {
+		return nil, fmt.Errorf("%w: default: %s", errors.ErrInvalidPolicy, err)
+	}
+
+	rules := cfg.Rules
+	if !cfg.DisableBuiltins {
+		rules = append(append([]config.PolicyRule{}, cfg.Rules...), builtins...)
+	}
+	for i, r := range rules {
+		compiled, err := compile(r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: rule %d (%s): %s", errors.ErrInvalidPolicy, i, r.Name, err)
+		}
+		e.rules = append(e.rules, compiled)
+	}
+	return e, nil
+}
+
+// Classify evaluates every command in a shell pipeline or list and returns the
+// most restrictive verdict.
+func (e *Engine) Classify(command string) Verdict {
+	normalized := harmlessRe.ReplaceAllString(command, "")
+	if redirectRe.MatchString(normalized) {
+		return Verdict{Command: command, Action: config.PolicyBlock, Rule: "redirect-outside-project", Reason: "redirects output to a path outside the project"}
+	}
+
+	var worst *Verdict
+	for _, segment := range separatorRe.Split(normalized, -1) {
+		fields := strings.Fields(segment)
+		for len(fields) > 0 && (envAssignRe.MatchString(fields[0]) || fields[0] == "env" || fields[0] == "exec") {
+			fields = fields[1:]
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		v := e.classifySegment(command, filepath.Base(strings.Trim(fields[0], `"'`)), strings.Join(fields[1:], " "))
+		if worst == nil || severity(v.Action) > severity(worst.Action) {
+			worst = &v
+		}
+	}
+	if worst == nil {
+		return Verdict{Command: command, Action: config.PolicyBlock, Rule: "empty", Reason: "is empty"}
+	}
+
+	if substitutionRe.MatchString(command) && severity(worst.Action) < severity(config.PolicyConfirm) {
+		return Verdict{Command: command, Action: config.PolicyConfirm, Rule: "command-substitution", Reason: "uses command substitution, which can't be inspected ahead of time"}
+	}
+	return *worst
+}
+
+func (e *Engine) classifySegment(command, executable, args string) Verdict {
+	for _, r := range e.rules {
+		if !r.matches(command, executable, args) {
+			continue
+		}
+		reason := r.Reason
+		if reason == "" {
+			reason = fmt.Sprintf("matched rule %q", r.Name)
+		}
+		return Verdict{Command: command, Action: r.Action, Rule: r.Name, Reason: fmt.Sprintf("%s %s", executable, reason)}
+	}
+	return Verdict{Command: command, Action: e.defaultAction, Rule: "default", Reason: fmt.Sprintf("%s isn't covered by any rule", executable)}
+}
+
+func (r rule) matches(command, executable, args string) bool {
+	if len(r.executables) > 0 && !r.executables[executable] {
+		return false
+	}
+	if r.args != nil && !r.args.MatchString(args) {
+		return false
+	}
+	if r.pattern != nil && !r.pattern.MatchString(command) {
+		return false
+	}
+	return true
+}
+
+func compile(r config.PolicyRule) (rule, error) {
+	c := rule{PolicyRule: r}
+	if err := validAction(r.Action); err != nil {
+		return c, err
+	}
+	if len(r.Executables) == 0 && r.Args == "" && r.Pattern == "" {
+		return c, fmt.Errorf("at least one of executables, args or pattern is required")
+	}
+	if len(r.Executables) > 0 {
+		c.executables = map[string]bool{}
+		for _, e := range r.Executables {
+			c.executables[e] = true
+		}
+	}
+	var err error
+	if r.Args != "" {
+		if c.args, err = regexp.Compile(r.Args); err != nil {
+			return c, err
+		}
+	}
+	if r.Pattern != "" {
+		if c.pattern, err = regexp.Compile(r.Pattern); err != nil {
+			return c, err
+		}
+	}
+	return c, nil
+}
+
+func validAction(a config.PolicyAction) error {
+	switch a {
+	case config.PolicyAllow, config.PolicyConfirm, config.PolicyBlock:
+		return nil
+	}
+	return fmt.Errorf("action must be one of allow, confirm or block, got %q", a)
+}
+
+func severity(a config.PolicyAction) int {
+	switch a {
+	case config.PolicyBlock:
+		return 2
+	case config.PolicyConfirm:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,401 @@
+package project
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/zachwalton/devoid/pkg/brain"
+)
+
+const (
+	maxScannedFiles = 5000
+	maxListedFiles  = 200
+)
+
+var (
+	skipDirs = map[string]bool{
+		".git":         true,
+		".hg":          true,
+		".svn":         true,
+		".devoid":      true,
+		".idea":        true,
+		".vscode":      true,
+		".venv":        true,
+		"venv":         true,
+		"node_modules": true,
+		"vendor":       true,
+		"dist":         true,
+		"build":        true,
+		"target":       true,
+		"__pycache__":  true,
+	}
+
+	extLanguages = map[string]string{
+		".go":    "go",
+		".py":    "python",
+		".js":    "javascript",
+		".jsx":   "javascript",
+		".mjs":   "javascript",
+		".ts":    "typescript",
+		".tsx":   "typescript",
+		".rb":    "ruby",
+		".rs":    "rust",
+		".java":  "java",
+		".kt":    "kotlin",
+		".c":     "c",
+		".h":     "c",
+		".cc":    "c++",
+		".cpp":   "c++",
+		".hpp":   "c++",
+		".cs":    "c#",
+		".php":   "php",
+		".swift": "swift",
+		".sh":    "shell",
+	}
+
+	buildFiles = map[string]bool{
+		"go.mod":           true,
+		"package.json":     true,
+		"tsconfig.json":    true,
+		"pyproject.toml":   true,
+		"setup.py":         true,
+		"requirements.txt": true,
+		"Pipfile":          true,
+		"Cargo.toml":       true,
+		"Gemfile":          true,
+		"pom.xml":          true,
+		"build.gradle":     true,
+		"build.gradle.kts": true,
+		"CMakeLists.txt":   true,
+		"Makefile":         true,
+		"Dockerfile":       true,
+	}
+
+	// frameworkHints maps a dependency name, as it appears in a build file, to
+	// the framework it implies.
+	frameworkHints = map[string]string{
+		"github.com/urfave/cli":              "urfave/cli",
+		"github.com/spf13/cobra":             "cobra",
+		"github.com/gin-gonic/gin":           "gin",
+		"github.com/labstack/echo":           "echo",
+		"github.com/go-chi/chi":              "chi",
+		"github.com/gofiber/fiber":           "fiber",
+		"github.com/charmbracelet/bubbletea": "bubbletea",
+		"django":                             "django",
+		"flask":                              "flask",
+		"fastapi":                            "fastapi",
+		"click":                              "click",
+		"react":                              "react",
+		"next":                               "next.js",
+		"vue":                                "vue",
+		"express":                            "express",
+		"rails":                              "rails",
+		"actix-web":                          "actix-web",
+		"clap":                               "clap",
+	}
+
+	testHints = map[string]string{
+		"pytest": "pytest",
+		"jest":   "jest",
+		"vitest": "vitest",
+		"mocha":  "mocha",
+		"rspec":  "rspec",
+	}
+
+	// dependencyRes match the framework and test dependencies at the start of
+	// a line in build files that list one per line, e.g. requirements.txt.
+	dependencyRes = func() map[string]*regexp.Regexp {
+		res := map[string]*regexp.Regexp{}
+		for _, hints := range []map[string]string{frameworkHints, testHints} {
+			for dep := range hints {
+				res[dep] = regexp.MustCompile(`(?im)^[\s"']*` + regexp.QuoteMeta(dep) + `\b`)
+			}
+		}
+		return res
+	}()
+
+	pyprojectNameRe = regexp.MustCompile(`(?m)^\s*name\s*=\s*"([^"]+)"`)
+	cargoNameRe     = regexp.MustCompile(`(?m)^\s*name\s*=\s*"([^"]+)"`)
+	// majorVersionRe matches the major version suffix of a Go module path,
+	// e.g. the v2 in example.com/app/v2.
+	majorVersionRe = regexp.MustCompile(`/v[0-9]+$`)
+)
+
+type (
+	// Summary is a compact description of an existing codebase.
+	Summary struct {
+		Path       string
+		Files      int
+		Truncated  bool
+		Languages  []Language
+		BuildFiles []string
+		Modules    []Module
+		Frameworks []string
+		Tests      []string
+		Layout     []string
+		Paths      []string
+	}
+
+	Language struct {
+		Name  string
+		Files int
+	}
+
+	Module struct {
+		Name string
+		File string
+	}
+)
+
+// Scan walks the project at path and summarizes its languages, build files,
+// top-level layout and module names.
+func Scan(path string) (*Summary, error) {
+	root, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Summary{Path: root}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("could not read project directory: %w", err)
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() {
+			if skipDirs[name] {
+				continue
+			}
+			name += "/"
+		}
+		s.Layout = append(s.Layout, name)
+	}
+
+	languages := map[string]int{}
+	frameworks := map[string]bool{}
+	tests := map[string]bool{}
+
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if p != root && skipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if s.Files >= maxScannedFiles {
+			s.Truncated = true
+			return filepath.SkipAll
+		}
+		s.Files++
+
+		rel, _ := filepath.Rel(root, p)
+		if len(s.Paths) < maxListedFiles {
+			s.Paths = append(s.Paths, filepath.ToSlash(rel))
+		}
+		name := d.Name()
+		if lang, ok := extLanguages[strings.ToLower(filepath.Ext(name))]; ok {
+			languages[lang]++
+		}
+		if strings.HasSuffix(name, "_test.go") {
+			tests["go test"] = true
+		}
+		if strings.HasPrefix(name, "test_") && strings.HasSuffix(name, ".py") {
+			tests["pytest"] = true
+		}
+		if !buildFiles[name] {
+			return nil
+		}
+		s.BuildFiles = append(s.BuildFiles, rel)
+
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+		if m := moduleName(name, content); m != "" {
+			s.Modules = append(s.Modules, Module{Name: m, File: rel})
+		}
+		for dep, framework := range frameworkHints {
+			if containsDependency(name, content, dep) {
+				frameworks[framework] = true
+			}
+		}
+		for dep, test := range testHints {
+			if containsDependency(name, content, dep) {
+				tests[test] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for name, count := range languages {
+		s.Languages = append(s.Languages, Language{Name: name, Files: count})
+	}
+	sort.Slice(s.Languages, func(i, j int) bool {
+		if s.Languages[i].Files == s.Languages[j].Files {
+			return s.Languages[i].Name < s.Languages[j].Name
+		}
+		return s.Languages[i].Files > s.Languages[j].Files
+	})
+	s.Frameworks = sortedKeys(frameworks)
+	s.Tests = sortedKeys(tests)
+	return s, nil
+}
+
+// Empty returns true if the scanned directory has no files worth evolving.
+func (s *Summary) Empty() bool {
+	return s.Files == 0
+}
+
+// Meta pre-populates stage metadata from what was found on disk. Fields that
+// couldn't be detected are left as "unset" so the model fills them in.
+func (s *Summary) Meta() brain.MetaPayload {
+	meta := brain.MetaPayload{
+		Name:           filepath.Base(s.Path),
+		Language:       "unset",
+		Test:           "unset",
+		Framework:      "unset",
+		Architecture:   "unset",
+		Description:    "unset",
+		Database:       "unset",
+		License:        "unset",
+		CI:             "unset",
+		Deployment:     "unset",
+		RuntimeVersion: "unset",
+		PackageManager: "unset",
+		CodeStyle:      "unset",
+		ProjectPath:    s.Path,
+		Existing:       true,
+	}
+	if len(s.Modules) > 0 {
+		meta.Name = path.Base(majorVersionRe.ReplaceAllString(s.Modules[0].Name, ""))
+	}
+	var languages []string
+	for _, l := range s.Languages {
+		languages = append(languages, l.Name)
+	}
+	if len(languages) > 0 {
+		meta.Language = strings.Join(languages, ", ")
+	}
+	if len(s.Tests) > 0 {
+		meta.Test = strings.Join(s.Tests, ", ")
+	}
+	if len(s.Frameworks) > 0 {
+		meta.Framework = strings.Join(s.Frameworks, ", ")
+	}
+	return meta
+}
+
+// String renders the summary compactly enough to include in a system prompt.
+func (s *Summary) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Files: %d", s.Files)
+	if s.Truncated {
+		b.WriteString(" (scan truncated)")
+	}
+	b.WriteString("\n")
+	if len(s.Languages) > 0 {
+		b.WriteString("Languages:")
+		for _, l := range s.Languages {
+			fmt.Fprintf(&b, " %s (%d files)", l.Name, l.Files)
+		}
+		b.WriteString("\n")
+	}
+	if len(s.BuildFiles) > 0 {
+		fmt.Fprintf(&b, "Build files: %s\n", strings.Join(s.BuildFiles, ", "))
+	}
+	for _, m := range s.Modules {
+		fmt.Fprintf(&b, "Module: %s (from %s)\n", m.Name, m.File)
+	}
+	if len(s.Frameworks) > 0 {
+		fmt.Fprintf(&b, "Frameworks: %s\n", strings.Join(s.Frameworks, ", "))
+	}
+	if len(s.Tests) > 0 {
+		fmt.Fprintf(&b, "Tests: %s\n", strings.Join(s.Tests, ", "))
+	}
+	if len(s.Layout) > 0 {
+		fmt.Fprintf(&b, "Top-level layout: %s\n", strings.Join(s.Layout, " "))
+	}
+	if len(s.Paths) > 0 {
+		b.WriteString("Existing files:\n")
+		for _, p := range s.Paths {
+			fmt.Fprintf(&b, "  %s\n", p)
+		}
+		if s.Files > len(s.Paths) {
+			fmt.Fprintf(&b, "  ... and %d more\n", s.Files-len(s.Paths))
+		}
+	}
+	return b.String()
+}
+
+func moduleName(file string, content []byte) string {
+	switch file {
+	case "go.mod":
+		scanner := bufio.NewScanner(strings.NewReader(string(content)))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if strings.HasPrefix(line, "module ") {
+				return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module ")), `"`)
+			}
+		}
+	case "package.json":
+		var pkg struct {
+			Name string `json:"name"`
+		}
+		if json.Unmarshal(content, &pkg) == nil {
+			return pkg.Name
+		}
+	case "pyproject.toml":
+		if m := pyprojectNameRe.FindSubmatch(content); m != nil {
+			return string(m[1])
+		}
+	case "Cargo.toml":
+		if m := cargoNameRe.FindSubmatch(content); m != nil {
+			return string(m[1])
+		}
+	}
+	return ""
+}
+
+func containsDependency(file string, content []byte, dep string) bool {
+	switch file {
+	case "go.mod":
+		return strings.Contains(string(content), dep)
+	case "package.json":
+		var pkg struct {
+			Dependencies    map[string]string `json:"dependencies"`
+			DevDependencies map[string]string `json:"devDependencies"`
+		}
+		if json.Unmarshal(content, &pkg) != nil {
+			return false
+		}
+		_, ok := pkg.Dependencies[dep]
+		_, devOK := pkg.DevDependencies[dep]
+		return ok || devOK
+	case "pyproject.toml", "requirements.txt", "Pipfile", "setup.py", "Cargo.toml", "Gemfile":
+		return dependencyRes[dep].Match(content)
+	}
+	return false
+}
+
+func sortedKeys(m map[string]bool) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
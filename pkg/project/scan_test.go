@@ -0,0 +1,148 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name       string
+		files      map[string]string
+		empty      bool
+		languages  []string
+		modules    []string
+		frameworks []string
+		tests      []string
+		layout     []string
+		meta       string
+	}{
+		{
+			name:  "empty directory",
+			empty: true,
+		},
+		{
+			name:  "only skipped directories",
+			files: map[string]string{".git/HEAD": "ref: refs/heads/main\n", "node_modules/x/index.js": ""},
+			empty: true,
+		},
+		{
+			name: "go module",
+			files: map[string]string{
+				"go.mod":           "module github.com/acme/app\n\nrequire github.com/spf13/cobra v1.8.0\n",
+				"main.go":          "package main\n",
+				"cmd/root.go":      "package cmd\n",
+				"cmd/root_test.go": "package cmd\n",
+				"vendor/x/x.go":    "package x\n",
+			},
+			languages:  []string{"go"},
+			modules:    []string{"github.com/acme/app"},
+			frameworks: []string{"cobra"},
+			tests:      []string{"go test"},
+			layout:     []string{"cmd/", "go.mod", "main.go"},
+			meta:       "app",
+		},
+		{
+			name: "go module with a major version",
+			files: map[string]string{
+				"go.mod":  "module github.com/acme/app/v2\n",
+				"main.go": "package main\n",
+			},
+			languages: []string{"go"},
+			modules:   []string{"github.com/acme/app/v2"},
+			layout:    []string{"go.mod", "main.go"},
+			meta:      "app",
+		},
+		{
+			name: "python project",
+			files: map[string]string{
+				"pyproject.toml":    "[project]\nname = \"service\"\n",
+				"requirements.txt":  "Django==5.0\npytest\n",
+				"app/views.py":      "",
+				"tests/test_app.py": "",
+			},
+			languages:  []string{"python"},
+			modules:    []string{"service"},
+			frameworks: []string{"django"},
+			tests:      []string{"pytest"},
+			layout:     []string{"app/", "pyproject.toml", "requirements.txt", "tests/"},
+			meta:       "service",
+		},
+		{
+			name: "dependency names end at a word boundary",
+			files: map[string]string{
+				"requirements.txt": "flask-cors\nclickhouse-driver\n",
+			},
+			frameworks: []string{"flask"},
+			layout:     []string{"requirements.txt"},
+		},
+		{
+			name: "node project",
+			files: map[string]string{
+				"package.json": `{"name": "@acme/web", "dependencies": {"react": "^18"}, "devDependencies": {"jest": "^29"}}`,
+				"src/App.tsx":  "",
+				"src/index.ts": "",
+				"src/util.js":  "",
+			},
+			languages:  []string{"typescript", "javascript"},
+			modules:    []string{"@acme/web"},
+			frameworks: []string{"react"},
+			tests:      []string{"jest"},
+			layout:     []string{"package.json", "src/"},
+			meta:       "web",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				p := filepath.Join(dir, name)
+				if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			s, err := Scan(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.Empty() != tt.empty {
+				t.Errorf("Empty() = %v, want %v", s.Empty(), tt.empty)
+			}
+			var languages, modules []string
+			for _, l := range s.Languages {
+				languages = append(languages, l.Name)
+			}
+			for _, m := range s.Modules {
+				modules = append(modules, m.Name)
+			}
+			if !slices.Equal(languages, tt.languages) {
+				t.Errorf("languages = %q, want %q", languages, tt.languages)
+			}
+			if !slices.Equal(modules, tt.modules) {
+				t.Errorf("modules = %q, want %q", modules, tt.modules)
+			}
+			if !slices.Equal(s.Frameworks, tt.frameworks) {
+				t.Errorf("frameworks = %q, want %q", s.Frameworks, tt.frameworks)
+			}
+			if !slices.Equal(s.Tests, tt.tests) {
+				t.Errorf("tests = %q, want %q", s.Tests, tt.tests)
+			}
+			if tt.layout != nil && !slices.Equal(s.Layout, tt.layout) {
+				t.Errorf("layout = %q, want %q", s.Layout, tt.layout)
+			}
+			want := tt.meta
+			if want == "" {
+				want = filepath.Base(dir)
+			}
+			if name := s.Meta().Name; name != want {
+				t.Errorf("Meta().Name = %q, want %q", name, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zachwalton/devoid/pkg/audit"
+	"github.com/zachwalton/devoid/pkg/brain"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// style is the stylesheet of HTML reports, which are self-contained so they
+// can be shared as a single file.
+const style = `
+body { margin: 0; background: #fafafa; color: #222; font: 16px/1.55 -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; }
+main { max-width: 960px; margin: 0 auto; padding: 2rem 1.5rem 4rem; }
+h1, h2, h3 { line-height: 1.25; }
+h2 { margin-top: 2.5rem; padding-bottom: .3rem; border-bottom: 1px solid #ddd; }
+blockquote { margin: 1rem 0; padding: .25rem 1rem; border-left: 4px solid #5f5fd7; background: #f0f0fa; color: #444; }
+code { font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; font-size: .9em; background: #eee; padding: .1em .3em; border-radius: 3px; }
+pre { overflow-x: auto; padding: 1rem; background: #f0f0f0; border-radius: 6px; }
+pre code { background: none; padding: 0; }
+table { border-collapse: collapse; width: 100%; margin: 1rem 0; font-size: .95em; }
+th, td { border: 1px solid #ddd; padding: .4rem .6rem; text-align: left; vertical-align: top; }
+th { background: #f0f0f0; }
+`
+
+// Markdown renders the report as a Markdown document.
+func (r *Report) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s design report\n\n", r.Name)
+	if r.Prompt != "" {
+		b.WriteString(quote(r.Prompt) + "\n")
+	}
+	if r.Model != "" {
+		fmt.Fprintf(&b, "* **Model:** %s\n", code(r.Model))
+	}
+	if len(r.Sessions) > 0 {
+		var ids []string
+		for _, id := range r.Sessions {
+			ids = append(ids, code(id))
+		}
+		fmt.Fprintf(&b, "* **Sessions:** %s\n", strings.Join(ids, ", "))
+	}
+	fmt.Fprintf(&b, "* **Generated:** %s\n", r.Generated.Format(time.DateTime))
+
+	if r.Meta != nil {
+		b.WriteString("\n## Design Choices\n\n")
+		if r.Meta.Description != "" && r.Meta.Description != "unset" {
+			b.WriteString(quote(r.Meta.Description) + "\n")
+		}
+		b.WriteString("| Choice | Value |\n| --- | --- |\n")
+		for _, row := range metaRows(r.Meta) {
+			fmt.Fprintf(&b, "| %s | %s |\n", cell(row[0]), cell(row[1]))
+		}
+	}
+
+	for _, st := range r.Stages {
+		fmt.Fprintf(&b, "\n## The %s stage\n\n", code(st.Name))
+		b.WriteString(st.status() + "\n")
+		if st.Result != nil && st.Result.StateMachine.Description != "" {
+			b.WriteString("\nThe model described its last changes as:\n\n" + quote(st.Result.StateMachine.Description))
+		}
+
+		if st.Result != nil && len(st.Result.AST) > 0 {
+			b.WriteString("\n### Planned Files\n\n| File | Action | Purpose | Depends on |\n| --- | --- | --- | --- |\n")
+			for _, n := range st.Result.AST {
+				var deps []string
+				for _, d := range n.DependsOn {
+					deps = append(deps, code(d))
+				}
+				fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", cell(code(n.Path)), n.Action, cell(n.Purpose), cell(strings.Join(deps, ", ")))
+			}
+		}
+
+		if len(st.Answers) > 0 {
+			b.WriteString("\n### Questions and Answers\n\n")
+			for _, a := range st.Answers {
+				answer := a.Answer
+				if a.Skipped {
+					answer = "_Skipped, left to the model._"
+				}
+				fmt.Fprintf(&b, "* **%s** %s\n", oneLine(a.Question), oneLine(answer))
+			}
+		}
+		if st.Result != nil && len(st.Result.StateMachine.Questions) > 0 {
+			b.WriteString("\n### Open Questions\n\nThe model still had these questions when the result was accepted:\n\n")
+			for _, q := range st.Result.StateMachine.Questions {
+				fmt.Fprintf(&b, "* %s\n", oneLine(q.Question))
+			}
+		}
+
+		if len(st.Decisions) > 0 {
+			b.WriteString("\n### Changes\n\n")
+			for _, d := range st.Decisions {
+				fmt.Fprintf(&b, "* Iteration %d, %s: %s\n", d.Iteration, d.Time.Format(time.DateTime), describe(d))
+				if d.Detail != "" && d.Kind != audit.DecisionRevert {
+					b.WriteString("\n" + indent(quote(d.Detail)) + "\n")
+				}
+			}
+		}
+
+		if len(st.Commands) > 0 {
+			b.WriteString("\n### Commands\n\n| Command | Policy | Status | Exit code |\n| --- | --- | --- | --- |\n")
+			for _, c := range st.Commands {
+				fmt.Fprintf(&b, "| %s | %s | %s | %d |\n", cell(code(c.Command)), cell(c.Action), cell(c.Status), c.ExitCode)
+			}
+		}
+
+		if len(st.Writes) > 0 {
+			b.WriteString("\n### Files Written\n\n| File | Action | Bytes | SHA-256 |\n| --- | --- | --- | --- |\n")
+			for _, w := range st.Writes {
+				fmt.Fprintf(&b, "| %s | %s | %d | %s |\n", cell(code(w.Path)), w.Action, w.Bytes, code(w.SHA256[:min(len(w.SHA256), 12)]))
+			}
+		}
+	}
+
+	if r.Graph != nil {
+		b.WriteString("\n## Dependency Graph\n\n")
+		b.WriteString(fence("mermaid", r.Graph.Mermaid()))
+	}
+	return b.String()
+}
+
+// HTML renders the report as a self-contained HTML document.
+func (r *Report) HTML() (string, error) {
+	var body bytes.Buffer
+	md := goldmark.New(goldmark.WithExtensions(extension.GFM))
+	if err := md.Convert([]byte(r.Markdown()), &body); err != nil {
+		return "", fmt.Errorf("could not render the report as HTML: %w", err)
+	}
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>%s design report</title>
+<style>%s</style>
+</head>
+<body>
+<main>
+%s</main>
+</body>
+</html>
+`, html.EscapeString(r.Name), style, body.String()), nil
+}
+
+// JSON renders the report as indented JSON.
+func (r *Report) JSON() (string, error) {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b) + "\n", nil
+}
+
+// status describes whether and when the stage was accepted.
+func (st Stage) status() string {
+	var s string
+	if st.Accepted.IsZero() {
+		s = "Not accepted yet."
+	} else {
+		s = fmt.Sprintf("Accepted at iteration %d on %s.", st.Iteration, st.Accepted.Format(time.DateTime))
+	}
+	switch st.ValidationErrors {
+	case 0:
+	case 1:
+		s += " 1 result was sent back to the model because it was invalid."
+	default:
+		s += fmt.Sprintf(" %d results were sent back to the model because they were invalid.", st.ValidationErrors)
+	}
+	return s
+}
+
+// describe says what a decision was.
+func describe(d Decision) string {
+	switch d.Kind {
+	case audit.DecisionChangeRequest:
+		return "requested changes"
+	case audit.DecisionReview:
+		return "gave feedback on the generated files"
+	case audit.DecisionEdit:
+		return "edited the result directly"
+	case audit.DecisionRevert:
+		return "reverted to " + d.Detail
+	}
+	return d.Kind
+}
+
+// metaRows returns the metadata that was decided, as label and value pairs.
+func metaRows(m *brain.MetaPayload) [][2]string {
+	var rows [][2]string
+	for _, f := range [][2]string{
+		{"Name", m.Name},
+		{"Language", m.Language},
+		{"Framework", m.Framework},
+		{"Architecture", m.Architecture},
+		{"Database", m.Database},
+		{"Test strategy", m.Test},
+		{"Test command", m.TestCommand},
+		{"Blueprint", m.Blueprint},
+		{"Minimum runtime version", m.RuntimeVersion},
+		{"Package manager", m.PackageManager},
+		{"Code style", m.CodeStyle},
+		{"License", m.License},
+		{"CI", m.CI},
+		{"Deployment", m.Deployment},
+	} {
+		if f[1] != "" && f[1] != "unset" {
+			rows = append(rows, f)
+		}
+	}
+	names := make([]string, 0, len(m.Custom))
+	for name := range m.Custom {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		rows = append(rows, [2]string{code(name), m.Custom[name]})
+	}
+	return rows
+}
+
+// cell makes s safe to put in a table cell.
+func cell(s string) string {
+	return strings.ReplaceAll(oneLine(s), "|", `\|`)
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// quote renders s as a block quote.
+func quote(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight("> "+l, " ")
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// indent nests s under a list item.
+func indent(s string) string {
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = "  " + l
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// code renders s as inline code, using a longer delimiter if s contains
+// backticks.
+func code(s string) string {
+	s = oneLine(s)
+	if !strings.Contains(s, "`") {
+		return "`" + s + "`"
+	}
+	f := strings.Repeat("`", longestRun(s)+1)
+	return f + " " + s + " " + f
+}
+
+// fence renders content as a code block.
+func fence(lang, content string) string {
+	f := strings.Repeat("`", max(3, longestRun(content)+1))
+	return fmt.Sprintf("%s%s\n%s\n%s\n", f, lang, strings.TrimSuffix(content, "\n"), f)
+}
+
+// longestRun returns the length of the longest run of backticks in s.
+func longestRun(s string) int {
+	longest, run := 0, 0
+	for _, c := range s {
+		if c == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return longest
+}
@@ -0,0 +1,98 @@
+package report
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zachwalton/devoid/pkg/brain"
+)
+
+func TestEscaping(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(string) string
+		in   string
+		want string
+	}{
+		{name: "cell", fn: cell, in: "a | b\nc", want: `a \| b c`},
+		{name: "code", fn: code, in: "go test", want: "`go test`"},
+		{name: "code with backticks", fn: code, in: "echo `date`", want: "`` echo `date` ``"},
+		{name: "code with a run of backticks", fn: code, in: "a ``` b", want: "```` a ``` b ````"},
+		{name: "quote", fn: quote, in: "one\n\ntwo\n", want: "> one\n>\n> two\n"},
+		{name: "indent", fn: indent, in: "> one\n> two\n", want: "  > one\n  > two\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fn(tt.in); got != tt.want {
+				t.Errorf("%s(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFence(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{content: "flowchart LR\n", want: "```mermaid\nflowchart LR\n```\n"},
+		{content: "a\n```\nb", want: "````mermaid\na\n```\nb\n````\n"},
+	}
+	for _, tt := range tests {
+		if got := fence("mermaid", tt.content); got != tt.want {
+			t.Errorf("fence(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	r := &Report{
+		Name:   "app",
+		Prompt: "Build a CLI\n# not a heading",
+		Meta: &brain.MetaPayload{
+			Name:      "app",
+			Language:  "go",
+			Framework: "cobra | viper",
+			Database:  "static json",
+			License:   "unset",
+			Custom:    map[string]string{"team`s": "platform"},
+		},
+		Stages: []Stage{{
+			Name: "ast",
+			Result: &brain.StagePayload{AST: []brain.FileNode{
+				{Path: "cmd/root.go", Action: brain.ActionCreate, Purpose: "Root | command\nwith flags", DependsOn: []string{"pkg/x.go"}},
+			}},
+			Commands: []brain.Command{{Command: "go test ./... | tee out", Action: "allow", Status: "ran"}},
+		}},
+	}
+	md := r.Markdown()
+	for _, want := range []string{
+		"> Build a CLI\n> # not a heading\n",
+		`| Framework | cobra \| viper |`,
+		"| Database | static json |",
+		"| `` team`s `` | platform |",
+		"| `cmd/root.go` | create | Root \\| command with flags | `pkg/x.go` |",
+		"| `go test ./... \\| tee out` | allow | ran | 0 |",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown() doesn't contain %q:\n%s", want, md)
+		}
+	}
+	if strings.Contains(md, "| License |") {
+		t.Errorf("Markdown() contains unset metadata:\n%s", md)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	r := &Report{Name: "<app>", Prompt: "<script>alert(1)</script>"}
+	got, err := r.HTML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got, "<script>") {
+		t.Errorf("HTML() contains raw HTML from the report:\n%s", got)
+	}
+	if !strings.Contains(got, "<title>&lt;app&gt; design report</title>") {
+		t.Errorf("HTML() doesn't escape the title:\n%s", got)
+	}
+}
@@ -0,0 +1,186 @@
+package report
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/zachwalton/devoid/pkg/audit"
+	"github.com/zachwalton/devoid/pkg/brain"
+	"github.com/zachwalton/devoid/pkg/checkpoint"
+	"github.com/zachwalton/devoid/pkg/errors"
+	"github.com/zachwalton/devoid/pkg/graph"
+	"github.com/zachwalton/devoid/pkg/workspace"
+)
+
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatJSON     = "json"
+)
+
+// Formats lists the supported export formats.
+var Formats = []string{FormatMarkdown, FormatHTML, FormatJSON}
+
+type (
+	// Report is the design history of a generated project: what each stage
+	// decided, the questions, change requests and edits that shaped it, and
+	// what was run and written.
+	Report struct {
+		Name      string    `json:"name"`
+		Prompt    string    `json:"prompt"`
+		Model     string    `json:"model"`
+		Generated time.Time `json:"generated"`
+		// Sessions are the IDs of the audit log sessions the report was
+		// compiled from, oldest first.
+		Sessions []string `json:"sessions"`
+		// Meta is the metadata of the latest accepted result, if any.
+		Meta   *brain.MetaPayload `json:"meta,omitempty"`
+		Stages []Stage            `json:"stages"`
+		// Graph is the dependency graph of the latest planned files, if any.
+		Graph *graph.Graph `json:"graph,omitempty"`
+	}
+
+	// Stage is what happened in a stage across the sessions.
+	Stage struct {
+		Name string `json:"name"`
+		// Iterations is the most iterations the stage took in a session.
+		Iterations int `json:"iterations"`
+		// ValidationErrors counts the results sent back to the model because
+		// they were invalid.
+		ValidationErrors int `json:"validation_errors"`
+		// Accepted is when the stage's latest result was accepted, or zero if
+		// it never was, in which case Iteration and Result aren't set.
+		Accepted  time.Time           `json:"accepted,omitempty"`
+		Iteration int                 `json:"iteration,omitempty"`
+		Result    *brain.StagePayload `json:"result,omitempty"`
+		Answers   []brain.Answer      `json:"answers,omitempty"`
+		Decisions []Decision          `json:"decisions,omitempty"`
+		Commands  []brain.Command     `json:"commands,omitempty"`
+		Writes    []workspace.Write   `json:"writes,omitempty"`
+	}
+
+	// Decision is a change the user made to a stage's result. Kind is one
+	// of the audit log's decisions, e.g. audit.DecisionChangeRequest.
+	Decision struct {
+		Time      time.Time `json:"time"`
+		Iteration int       `json:"iteration"`
+		Kind      string    `json:"kind"`
+		Detail    string    `json:"detail,omitempty"`
+	}
+)
+
+// Build compiles the report for a project from its checkpoints, which are the
+// latest accepted result of each stage, and the sessions in its audit log. If
+// session isn't empty, only that session's events are included.
+func Build(projectPath, session string) (*Report, error) {
+	checkpoints, err := checkpoint.All(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	var sessions []audit.Session
+	if session != "" {
+		s, err := audit.Find(projectPath, session)
+		if err != nil {
+			return nil, err
+		}
+		sessions = []audit.Session{*s}
+	} else if sessions, err = audit.Sessions(projectPath); err != nil {
+		return nil, err
+	}
+	if len(checkpoints) == 0 && len(sessions) == 0 {
+		return nil, fmt.Errorf("%w: no checkpoints or sessions in %s", errors.ErrNothingToReport, projectPath)
+	}
+
+	r := &Report{Generated: time.Now(), Sessions: []string{}, Stages: []Stage{}}
+	for _, s := range sessions {
+		events, err := audit.Read(s.Path)
+		if err != nil {
+			return nil, err
+		}
+		r.Sessions = append(r.Sessions, s.ID)
+		for _, e := range events {
+			if e.Type == audit.EventSessionStart {
+				r.Prompt, r.Model = e.Prompt, e.Model
+			}
+			if e.Stage == "" {
+				continue
+			}
+			st := r.stage(e.Stage)
+			st.Iterations = max(st.Iterations, e.Iteration)
+			switch e.Type {
+			case audit.EventValidation:
+				st.ValidationErrors++
+			case audit.EventCommand:
+				if e.Command != nil {
+					st.Commands = append(st.Commands, *e.Command)
+				}
+			case audit.EventDecision:
+				switch e.Decision {
+				case audit.DecisionAnswers:
+					st.Answers = append(st.Answers, e.Answers...)
+				case audit.DecisionEdit:
+					// The edited result is in the checkpoint or a later
+					// iteration, so it isn't repeated.
+					st.Decisions = append(st.Decisions, Decision{Time: e.Time, Iteration: e.Iteration, Kind: e.Decision})
+				case audit.DecisionReview, audit.DecisionChangeRequest, audit.DecisionRevert:
+					st.Decisions = append(st.Decisions, Decision{Time: e.Time, Iteration: e.Iteration, Kind: e.Decision, Detail: e.Detail})
+				}
+			}
+		}
+	}
+
+	// Checkpoints are sorted oldest first, so the latest metadata and
+	// planned files win.
+	for _, c := range checkpoints {
+		st := r.stage(c.Stage)
+		st.Accepted, st.Iteration, st.Result, st.Writes = c.Time, c.Iteration, c.Payload, c.Writes
+		st.Iterations = max(st.Iterations, c.Iteration)
+		if r.Prompt == "" {
+			r.Prompt, r.Model = c.Prompt, c.Model
+		}
+		if c.Payload == nil {
+			continue
+		}
+		meta := c.Payload.Meta
+		r.Meta = &meta
+		if len(c.Payload.AST) > 0 {
+			r.Graph = graph.New(meta.Name, c.Payload.AST)
+		}
+	}
+
+	r.Name = filepath.Base(projectPath)
+	if abs, err := filepath.Abs(projectPath); err == nil {
+		r.Name = filepath.Base(abs)
+	}
+	if r.Meta != nil && r.Meta.Name != "" && r.Meta.Name != "unset" {
+		r.Name = r.Meta.Name
+	}
+	return r, nil
+}
+
+// stage returns the named stage, adding it if it isn't in the report yet, so
+// stages are in the order they were first seen.
+func (r *Report) stage(name string) *Stage {
+	i := slices.IndexFunc(r.Stages, func(s Stage) bool { return s.Name == name })
+	if i < 0 {
+		r.Stages = append(r.Stages, Stage{Name: name})
+		i = len(r.Stages) - 1
+	}
+	return &r.Stages[i]
+}
+
+// Export renders the report in one of Formats.
+func (r *Report) Export(format string) (string, error) {
+	switch format {
+	case FormatMarkdown:
+		return r.Markdown(), nil
+	case FormatHTML:
+		return r.HTML()
+	case FormatJSON:
+		return r.JSON()
+	}
+	return "", fmt.Errorf("%w: %q, must be one of %s", errors.ErrUnknownFormat, format, strings.Join(Formats, ", "))
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package runner
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killGroup starts c in its own process group and kills the whole group when
+// its context is done, so processes it started, such as the command forked
+// into the sandbox or jobs backgrounded by the shell, don't outlive it.
+func killGroup(c *exec.Cmd) {
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	c.Cancel = func() error {
+		return syscall.Kill(-c.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,7 @@
+package runner
+
+import "os/exec"
+
+// killGroup is a no-op on Windows, where only the command itself is killed
+// when its context is done.
+func killGroup(c *exec.Cmd) {}
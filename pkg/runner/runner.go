@@ -0,0 +1,125 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	goerrors "errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/zachwalton/devoid/pkg/config"
+	"github.com/zachwalton/devoid/pkg/errors"
+)
+
+const (
+	defaultTimeout    = 10 * time.Minute
+	defaultCPUSeconds = 300
+	defaultMemoryMB   = 4096
+	// waitDelay is how long to wait for output after a command is killed,
+	// in case something it started still holds the pipes open.
+	waitDelay = 5 * time.Second
+)
+
+type (
+	Result struct {
+		Command   string
+		ExitCode  int
+		Output    string
+		Sandboxed bool
+	}
+
+	Runner struct {
+		cfg       config.Sandbox
+		sandboxed bool
+	}
+)
+
+// New returns a Runner that executes commands in a sandbox. If the sandbox
+// isn't available, commands only run unsandboxed when that's explicitly
+// allowed in cfg.
+func New(cfg config.Sandbox) (*Runner, error) {
+	r := &Runner{cfg: cfg}
+	err := sandboxAvailable()
+	switch {
+	case err == nil:
+		r.sandboxed = true
+	case cfg.AllowUnsandboxed:
+		log.Warn("sandbox unavailable, commands will run with your full privileges", "reason", err)
+	default:
+		return nil, fmt.Errorf(
+			"%w: %s. Set sandbox.allow-unsandboxed to run commands without it",
+			errors.ErrSandboxUnavailable, err,
+		)
+	}
+	return r, nil
+}
+
+// Run executes command with the shell in dir, creating dir if needed.
+func (r *Runner) Run(ctx context.Context, dir, command string) (*Result, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	timeout := limit(int(r.cfg.Timeout), int(defaultTimeout))
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout))
+		defer cancel()
+	}
+
+	var (
+		c       *exec.Cmd
+		cleanup = func() {}
+		err     error
+	)
+	if r.sandboxed {
+		c, cleanup, err = r.sandboxCommand(ctx, dir, command)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		c = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	defer cleanup()
+
+	killGroup(c)
+	c.WaitDelay = waitDelay
+
+	var out bytes.Buffer
+	c.Dir = dir
+	c.Stdout = &out
+	c.Stderr = &out
+
+	res := &Result{Command: command, Sandboxed: r.sandboxed}
+	err = c.Run()
+	res.Output = out.String()
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case ctx.Err() == context.DeadlineExceeded:
+		res.ExitCode = -1
+		return res, fmt.Errorf("%w: %q timed out after %s", errors.ErrCommandFailed, command, time.Duration(timeout))
+	case goerrors.As(err, &exitErr):
+		res.ExitCode = exitErr.ExitCode()
+		return res, fmt.Errorf("%w: %q exited with %d", errors.ErrCommandFailed, command, res.ExitCode)
+	default:
+		res.ExitCode = -1
+		return res, fmt.Errorf("%w: %q: %s", errors.ErrCommandFailed, command, err)
+	}
+	return res, nil
+}
+
+// limit returns v, or def if v is unset. Negative values disable the limit.
+func limit(v, def int) int {
+	switch {
+	case v < 0:
+		return 0
+	case v == 0:
+		return def
+	}
+	return v
+}
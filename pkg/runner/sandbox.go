@@ -0,0 +1,142 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+)
+
+// sandboxScript runs inside new user, mount and PID (and optionally network)
+// namespaces. The first writable path is the project directory. It bind
+// mounts the writable paths onto themselves, remounts every other mount
+// read-only, applies resource limits and then runs the command. Remounts keep
+// each mount's nosuid, nodev, noexec and atime flags, which can't be cleared
+// in a user namespace, and the sandbox fails rather than run the command if
+// any mount can't be made read-only. Mountpoints in mountinfo escape spaces
+// and other special characters as octal, e.g. \040, which is undone before
+// they're compared with the writable paths.
+const sandboxScript = `set -e
+command="$1"; cpu="$2"; memory="$3"; shift 3
+for p in "$@"; do mount --bind "$p" "$p"; done
+while read -r _ _ _ _ mp opts _; do
+	mp=$(printf '%s' "$mp" | sed 's/\\\([0-7][0-7][0-7]\)/\\0\1/g')
+	mp=$(printf '%b' "$mp")
+	keep=
+	for p in "$@"; do [ "$mp" = "$p" ] && keep=1; done
+	[ -n "$keep" ] && continue
+	mount -o "remount,bind,ro${opts#r[ow]}" "$mp" || {
+		echo "devoid-sandbox: could not make $mp read-only" >&2
+		exit 1
+	}
+done < /proc/self/mountinfo
+# Re-enter the project directory so the working directory is the writable bind
+# mount rather than the read-only directory underneath it.
+cd "$1"
+[ "$cpu" -gt 0 ] && ulimit -t "$cpu"
+[ "$memory" -gt 0 ] && ulimit -v "$((memory * 1024))"
+exec sh -c "$command"
+`
+
+// sandboxEnv are the environment variables passed into the sandbox. Nothing
+// else is, so credentials such as API tokens don't reach commands that have
+// network access; sandbox.env adds to the list.
+var sandboxEnv = []string{
+	"PATH", "HOME", "USER", "LOGNAME", "SHELL", "TERM", "TZ",
+	"LANG", "LC_ALL", "LC_CTYPE", "LC_MESSAGES",
+	"GOPATH", "GOFLAGS", "GOTOOLCHAIN", "GOCACHE", "GOMODCACHE",
+	"XDG_CACHE_HOME", "npm_config_cache", "PIP_CACHE_DIR",
+}
+
+func sandboxAvailable() error {
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("namespaces are only supported on linux, not %s", runtime.GOOS)
+	}
+	if _, err := exec.LookPath("unshare"); err != nil {
+		return fmt.Errorf("unshare wasn't found: %w", err)
+	}
+	if out, err := exec.Command("unshare", "--user", "--map-root-user", "--mount", "true").CombinedOutput(); err != nil {
+		return fmt.Errorf("unprivileged user namespaces aren't permitted: %s", out)
+	}
+	return nil
+}
+
+// sandboxCommand builds the command that runs command confined to dir. The
+// returned cleanup func removes the scratch directory used for caches and
+// temporary files.
+func (r *Runner) sandboxCommand(ctx context.Context, dir, command string) (*exec.Cmd, func(), error) {
+	project, err := resolve(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	scratch, err := os.MkdirTemp("", "devoid-sandbox-")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() { os.RemoveAll(scratch) }
+	if scratch, err = resolve(scratch); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	tmp := filepath.Join(scratch, "tmp")
+	if err := os.Mkdir(tmp, 0o700); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	writable := []string{project, scratch}
+	for _, p := range r.cfg.WritablePaths {
+		resolved, err := resolve(p)
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("could not resolve sandbox writable path: %w", err)
+		}
+		writable = append(writable, resolved)
+	}
+
+	args := []string{"--user", "--map-root-user", "--mount", "--pid", "--kill-child", "--mount-proc"}
+	if r.cfg.NoNetwork {
+		args = append(args, "--net")
+	}
+	args = append(args,
+		"sh", "-c", sandboxScript, "devoid-sandbox", command,
+		strconv.Itoa(limit(r.cfg.CPUSeconds, defaultCPUSeconds)),
+		strconv.Itoa(limit(r.cfg.MemoryMB, defaultMemoryMB)),
+	)
+	args = append(args, writable...)
+
+	c := exec.CommandContext(ctx, "unshare", args...)
+	c.Env = []string{"TMPDIR=" + tmp}
+	for _, key := range append(slices.Clone(sandboxEnv), r.cfg.Env...) {
+		if value, ok := os.LookupEnv(key); ok {
+			c.Env = append(c.Env, key+"="+value)
+		}
+	}
+	// Toolchain caches normally live in the home directory, which is read-only
+	// inside the sandbox, so point them at the scratch directory unless they've
+	// been set explicitly, e.g. to a path in sandbox.writable-paths.
+	for key, value := range map[string]string{
+		"XDG_CACHE_HOME":   filepath.Join(scratch, "cache"),
+		"GOCACHE":          filepath.Join(scratch, "cache", "go-build"),
+		"GOMODCACHE":       filepath.Join(scratch, "go", "pkg", "mod"),
+		"npm_config_cache": filepath.Join(scratch, "npm"),
+		"PIP_CACHE_DIR":    filepath.Join(scratch, "cache", "pip"),
+	} {
+		if _, ok := os.LookupEnv(key); !ok {
+			c.Env = append(c.Env, key+"="+value)
+		}
+	}
+	return c, cleanup, nil
+}
+
+func resolve(p string) (string, error) {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
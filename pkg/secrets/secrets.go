@@ -0,0 +1,256 @@
+package secrets
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/zachwalton/devoid/pkg/config"
+	"github.com/zachwalton/devoid/pkg/errors"
+)
+
+const (
+	// minEntropy is the Shannon entropy, in bits per character, above which
+	// a quoted string is treated as a likely secret.
+	minEntropy = 4.2
+	// minValueLength is the shortest assigned value the generic rule flags.
+	minValueLength = 8
+)
+
+var (
+	builtins = []rule{
+		{name: "aws-access-key-id", description: "AWS access key ID", re: `\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`, placeholders: true},
+		{name: "aws-secret-access-key", description: "AWS secret access key", re: `(?i)aws.{0,20}?(?:secret|private).{0,20}?['"\s:=]+([A-Za-z0-9/+=]{40})\b`, group: 1, placeholders: true},
+		{name: "gcp-api-key", description: "Google Cloud API key", re: `\bAIza[0-9A-Za-z_\-]{35}\b`},
+		{name: "github-token", description: "GitHub token", re: `\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`},
+		{name: "gitlab-token", description: "GitLab token", re: `\bglpat-[A-Za-z0-9_\-]{20,}\b`},
+		{name: "slack-token", description: "Slack token", re: `\bxox[baprs]-[A-Za-z0-9-]{10,}\b`},
+		{name: "stripe-key", description: "Stripe live key", re: `\b(?:sk|rk)_live_[A-Za-z0-9]{20,}\b`},
+		{name: "llm-api-key", description: "LLM provider API key", re: `\bsk-[A-Za-z0-9_\-]{32,}\b`},
+		{name: "private-key", description: "private key", re: `-----BEGIN [A-Z ]*PRIVATE KEY[A-Z ]*-----(?s:.*?)(?:-----END [A-Z ]*PRIVATE KEY[A-Z ]*-----|\z)`},
+		{name: "jwt", description: "JSON Web Token", re: `\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`},
+		{name: "url-credentials", description: "password in a URL", re: `\b[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@'"]+:([^/\s:@'"]{3,})@`, group: 1, placeholders: true},
+		{name: "hardcoded-secret", description: "hardcoded password, token or key", re: `(?i)[a-z0-9_.-]*(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|private[_-]?key|auth[_-]?key)[a-z0-9_.-]*["']?\s*(?::=|[:=])\s*["']([^"'\s]+)["']`, group: 1, minLength: minValueLength, placeholders: true},
+	}
+
+	entropyRule = rule{name: "high-entropy-string", description: "high-entropy string", re: `["'\x60]([A-Za-z0-9+/_\-=]{24,})["'\x60]`, group: 1, placeholders: true, entropy: true}
+
+	// placeholders are substrings that mark a value as an obvious stand-in
+	// rather than a real secret.
+	placeholders = []string{
+		"example", "changeme", "change-me", "change_me", "placeholder", "your", "xxxx", "****", "...",
+		"<", "${", "{{", "%s", "redacted", "dummy", "fake", "sample", "password", "todo", "replace",
+		"getenv", "process.env", "environ",
+	}
+
+	// entropyExempt are files full of legitimate high-entropy strings, e.g.
+	// checksums.
+	entropyExempt = map[string]bool{
+		"go.sum": true, "package-lock.json": true, "yarn.lock": true, "pnpm-lock.yaml": true,
+		"Cargo.lock": true, "poetry.lock": true, "Pipfile.lock": true, "composer.lock": true, "Gemfile.lock": true,
+	}
+)
+
+type (
+	// Scanner finds likely secrets in generated content and commands.
+	Scanner struct {
+		action  config.SecretsAction
+		rules   []rule
+		allow   []*regexp.Regexp
+		entropy bool
+	}
+
+	// Finding is a likely secret. Its value is never stored, only a short
+	// preview of it.
+	Finding struct {
+		Rule        string `json:"rule"`
+		Description string `json:"description"`
+		// Path is the file the secret was found in, or where in the payload
+		// it was found for anything else, e.g. "bootstrap -> 0".
+		Path    string `json:"path"`
+		Line    int    `json:"line"`
+		Preview string `json:"preview"`
+
+		start, end int
+	}
+
+	rule struct {
+		name        string
+		description string
+		re          string
+		// group is the submatch holding the secret, or 0 for the whole match.
+		group     int
+		minLength int
+		// placeholders skips values that are obviously stand-ins.
+		placeholders bool
+		entropy      bool
+		compiled     *regexp.Regexp
+	}
+)
+
+// New returns a scanner for the configured action and allowlist.
+func New(cfg config.Secrets) (*Scanner, error) {
+	s := &Scanner{action: cfg.Action, entropy: !cfg.DisableEntropy}
+	if s.action == "" {
+		s.action = config.SecretsRedact
+	}
+	if s.action != config.SecretsRedact && s.action != config.SecretsBlock {
+		return nil, fmt.Errorf("%w: secrets -> action must be redact or block, got %q", errors.ErrInvalidConfig, s.action)
+	}
+	rules := builtins
+	if s.entropy {
+		rules = append(append([]rule{}, builtins...), entropyRule)
+	}
+	for _, r := range rules {
+		r.compiled = regexp.MustCompile(r.re)
+		s.rules = append(s.rules, r)
+	}
+	for i, a := range cfg.Allow {
+		re, err := regexp.Compile(a)
+		if err != nil {
+			return nil, fmt.Errorf("%w: secrets -> allow -> %d: %s", errors.ErrInvalidConfig, i, err)
+		}
+		s.allow = append(s.allow, re)
+	}
+	return s, nil
+}
+
+// Block returns true if content with findings should be rejected rather than
+// redacted.
+func (s *Scanner) Block() bool {
+	return s.action == config.SecretsBlock
+}
+
+// Scan returns the likely secrets in content, in order and without overlaps.
+// name is recorded on each finding and, for files, used to skip checks that
+// don't apply to them.
+func (s *Scanner) Scan(name, content string) []Finding {
+	var findings []Finding
+	for _, r := range s.rules {
+		if r.entropy && entropyExempt[path.Base(name)] {
+			continue
+		}
+		for _, m := range r.compiled.FindAllStringSubmatchIndex(content, -1) {
+			start, end := m[2*r.group], m[2*r.group+1]
+			if start < 0 {
+				continue
+			}
+			value := content[start:end]
+			if len(value) < r.minLength || s.allowed(value) {
+				continue
+			}
+			if r.placeholders && placeholder(value) {
+				continue
+			}
+			if r.entropy && !random(value) {
+				continue
+			}
+			findings = append(findings, Finding{
+				Rule:        r.name,
+				Description: r.description,
+				Path:        name,
+				Line:        strings.Count(content[:start], "\n") + 1,
+				Preview:     preview(value),
+				start:       start,
+				end:         end,
+			})
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].start < findings[j].start })
+	var kept []Finding
+	for _, f := range findings {
+		if len(kept) > 0 && f.start < kept[len(kept)-1].end {
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept
+}
+
+// Redact replaces each finding in content, which must be the content the
+// findings were scanned from, with a marker naming the rule that matched.
+func Redact(content string, findings []Finding) string {
+	var b strings.Builder
+	last := 0
+	for _, f := range findings {
+		if f.start < last || f.end > len(content) {
+			continue
+		}
+		b.WriteString(content[last:f.start])
+		b.WriteString(Marker(f.Rule))
+		last = f.end
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
+
+// Marker is what a redacted secret is replaced with.
+func Marker(rule string) string {
+	return "REDACTED_" + strings.ToUpper(strings.ReplaceAll(rule, "-", "_"))
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s line %d: %s (%s)", f.Path, f.Line, f.Description, f.Preview)
+}
+
+func (s *Scanner) allowed(value string) bool {
+	for _, re := range s.allow {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return strings.HasPrefix(value, "REDACTED_")
+}
+
+func placeholder(value string) bool {
+	lower := strings.ToLower(value)
+	for _, p := range placeholders {
+		if strings.Contains(lower, p) {
+			return true
+		}
+	}
+	// Runs of a single character, e.g. 000000000, aren't secrets.
+	return strings.Count(value, value[:1]) == len(value)
+}
+
+// random returns true if value mixes character classes and has high entropy,
+// which rules out identifiers, paths and hex checksums.
+func random(value string) bool {
+	var upper, lower, digit bool
+	for _, c := range value {
+		switch {
+		case c >= 'A' && c <= 'Z':
+			upper = true
+		case c >= 'a' && c <= 'z':
+			lower = true
+		case c >= '0' && c <= '9':
+			digit = true
+		}
+	}
+	return upper && lower && digit && entropy(value) >= minEntropy
+}
+
+func entropy(s string) float64 {
+	counts := map[rune]int{}
+	for _, c := range s {
+		counts[c]++
+	}
+	var h float64
+	n := float64(len(s))
+	for _, c := range counts {
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+func preview(value string) string {
+	if len(value) <= 8 {
+		return strings.Repeat("*", len(value))
+	}
+	return value[:4] + strings.Repeat("*", 4) + fmt.Sprintf(" (%d chars)", len(value))
+}
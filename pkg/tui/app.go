@@ -0,0 +1,447 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/muesli/termenv"
+	"golang.org/x/term"
+
+	"github.com/zachwalton/devoid/pkg/config"
+	"github.com/zachwalton/devoid/pkg/errors"
+)
+
+type screen int
+
+const (
+	// screenIdle shows nothing, so log lines printed above the program are
+	// the only output.
+	screenIdle screen = iota
+	screenSummary
+	screenDiff
+	screenChoices
+	screenInput
+	screenStreaming
+	screenForm
+	screenCompose
+	screenTree
+)
+
+var (
+	defaultApp  *App
+	defaultOnce sync.Once
+	// ui is how the App looks, set by Configure.
+	ui config.UI
+)
+
+type (
+	// App is the single Bubble Tea program used for the whole session. The
+	// state machine drives it by sending a message for each screen it wants
+	// to show, then waiting on a reply channel for the user's response.
+	App struct {
+		program *tea.Program
+		// mu serializes screens, so only one is waiting on the user at once.
+		mu          sync.Mutex
+		done        chan struct{}
+		interrupted atomic.Bool
+		// plain is set when screens are printed line by line instead of run
+		// in a program.
+		plain *plain
+	}
+
+	showMsg struct {
+		screen  screen
+		title   string
+		content string
+		reply   chan any
+	}
+
+	chooseMsg struct {
+		title string
+		items []string
+		reply chan any
+	}
+
+	inputMsg struct {
+		prompt string
+		reply  chan any
+	}
+
+	streamMsg struct {
+		title  string
+		cancel context.CancelFunc
+	}
+
+	chunkMsg string
+
+	stopStreamMsg struct {
+		reply chan any
+	}
+
+	appModel struct {
+		app    *App
+		screen screen
+		// termWidth and termHeight are the size of the terminal, and width
+		// and height the space left for the screen beside the timeline.
+		termWidth  int
+		termHeight int
+		width      int
+		height     int
+		timeline   []TimelineStage
+		title      string
+		// reply receives the result of the current screen.
+		reply chan any
+
+		content  string
+		viewport viewport.Model
+		list     list.Model
+		input    textinput.Model
+		form     *form
+		composer *composer
+		tree     *tree
+		spinner  spinner.Model
+		streamed string
+		cancel   context.CancelFunc
+	}
+)
+
+// Configure sets the theme and whether to use the plain renderer. NO_COLOR
+// disables colors, and the plain renderer is used when stdout isn't a
+// terminal. It should be called before the App is started.
+func Configure(cfg config.UI) error {
+	if cfg.Theme.Markdown != "" {
+		if _, err := glamour.NewTermRenderer(glamour.WithStylePath(cfg.Theme.Markdown)); err != nil {
+			return fmt.Errorf("%w: ui.theme.markdown: %q isn't a glamour style or style file: %s", errors.ErrInvalidConfig, cfg.Theme.Markdown, err)
+		}
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		cfg.NoColor = true
+	}
+	if cfg.NoColor {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		cfg.Plain = true
+	}
+	setTheme(cfg.Theme)
+	ui = cfg
+	return nil
+}
+
+// Start runs a new App until Close is called or the user interrupts it with
+// ctrl+c. Log output is printed above the program while it runs. With the
+// plain renderer, screens are printed instead and log output goes to stderr.
+func Start() *App {
+	if ui.Plain {
+		return startPlain()
+	}
+	a := &App{done: make(chan struct{})}
+	// Detecting the background color queries the terminal, whose reply would
+	// be read as key presses once the program is running, so do it once
+	// up front.
+	detectMarkdownStyle()
+	s := spinner.New()
+	s.Spinner = spinner.Moon
+	a.program = tea.NewProgram(&appModel{app: a, termWidth: defaultWidth, termHeight: defaultHeight, width: defaultWidth, height: defaultHeight, spinner: s})
+	log.SetColorProfile(colorProfile())
+	log.SetOutput(logWriter{a})
+	go func() {
+		// done is closed first: a log call blocked on the program holds the
+		// logger's lock until then, which SetOutput needs.
+		defer log.SetOutput(os.Stderr)
+		defer close(a.done)
+		if _, err := a.program.Run(); err != nil {
+			fmt.Fprintln(os.Stderr, "could not run the terminal UI:", err)
+			a.interrupted.Store(true)
+		}
+	}()
+	return a
+}
+
+// Default returns the App used by the package-level functions, starting it
+// on first use.
+func Default() *App {
+	defaultOnce.Do(func() { defaultApp = Start() })
+	return defaultApp
+}
+
+// Close stops the default App, if it was started, and restores the terminal.
+func Close() {
+	if defaultApp != nil {
+		defaultApp.Close()
+	}
+}
+
+// Interrupted returns true if the user pressed ctrl+c in the default App.
+// Screens shown after that return immediately with empty results.
+func Interrupted() bool {
+	return defaultApp != nil && defaultApp.interrupted.Load()
+}
+
+func (a *App) Close() {
+	if a.plain != nil {
+		close(a.done)
+		return
+	}
+	a.program.Quit()
+	<-a.done
+}
+
+// Println prints a line above the program, where it persists across screens.
+func Println(s string) {
+	Default().println(s)
+}
+
+func (a *App) println(s string) {
+	if a.plain != nil {
+		a.plain.println(s)
+		return
+	}
+	// Program.Println blocks forever once the program has stopped, so give up
+	// on it when that happens and write to stderr instead.
+	sent := make(chan struct{})
+	go func() {
+		a.program.Println(s)
+		close(sent)
+	}()
+	select {
+	case <-sent:
+	case <-a.done:
+		fmt.Fprintln(os.Stderr, s)
+	}
+}
+
+// echo prints the user's answer above the program, so it stays visible once
+// the screen closes. The plain renderer leaves answers where they were typed.
+func (a *App) echo(answer string) {
+	if answer != "" && a.plain == nil {
+		a.println(quitTextStyle.Render("> " + answer))
+	}
+}
+
+// request sends msg to the program and waits for the reply, or returns an
+// nil if the program has stopped.
+func (a *App) request(msg tea.Msg, reply chan any) any {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.interrupted.Load() {
+		return nil
+	}
+	select {
+	case <-a.done:
+		return nil
+	default:
+	}
+	if a.plain != nil {
+		return a.plainRequest(msg)
+	}
+	a.program.Send(msg)
+	select {
+	case r := <-reply:
+		return r
+	case <-a.done:
+		return nil
+	}
+}
+
+func (m *appModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.termWidth, m.termHeight = msg.Width, msg.Height
+		m.layout()
+		return m, nil
+	case timelineMsg:
+		m.timeline = msg
+		m.layout()
+		return m, nil
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyCtrlC {
+			return m, m.interrupt()
+		}
+	case showMsg:
+		m.open(msg.screen, msg.title, msg.reply)
+		m.content = msg.content
+		m.layoutViewport()
+		return m, nil
+	case chooseMsg:
+		m.open(screenChoices, msg.title, msg.reply)
+		m.list = newList(msg.title, msg.items, m.width, m.height)
+		return m, nil
+	case inputMsg:
+		m.open(screenInput, msg.prompt, msg.reply)
+		m.input = newInput(m.width)
+		return m, textinput.Blink
+	case formMsg:
+		m.open(screenForm, msg.title, msg.reply)
+		m.form = newForm(msg.problem, msg.fields, m.width)
+		return m, tea.Batch(textinput.Blink, textarea.Blink)
+	case composeMsg:
+		m.open(screenCompose, msg.prompt, msg.reply)
+		m.composer = newComposer(msg, m.width)
+		return m, textarea.Blink
+	case treeMsg:
+		m.open(screenTree, msg.title, msg.reply)
+		m.tree = newTree(msg, m.width)
+		return m, nil
+	case streamMsg:
+		m.open(screenStreaming, msg.title, nil)
+		m.streamed, m.cancel = "", msg.cancel
+		return m, m.spinner.Tick
+	case chunkMsg:
+		if m.screen == screenStreaming {
+			m.streamed += string(msg)
+		}
+		return m, nil
+	case stopStreamMsg:
+		if m.screen == screenStreaming {
+			m.screen, m.cancel = screenIdle, nil
+		}
+		msg.reply <- nil
+		return m, nil
+	case spinner.TickMsg:
+		// Ticks stop once nothing is streaming, and restart with the next
+		// stream.
+		if m.screen != screenStreaming {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+
+	switch m.screen {
+	case screenSummary, screenDiff:
+		return m.updateViewport(msg)
+	case screenChoices:
+		return m.updateList(msg)
+	case screenInput:
+		return m.updateInput(msg)
+	case screenForm:
+		return m.updateForm(msg)
+	case screenCompose:
+		return m.updateCompose(msg)
+	case screenTree:
+		return m.updateTree(msg)
+	case screenStreaming:
+		if key, ok := msg.(tea.KeyMsg); ok && key.Type == tea.KeyEsc && m.cancel != nil {
+			m.cancel()
+		}
+	}
+	return m, nil
+}
+
+func (m *appModel) View() string {
+	return m.withTimeline(m.screenView())
+}
+
+func (m *appModel) screenView() string {
+	switch m.screen {
+	case screenSummary, screenDiff:
+		return m.viewportView()
+	case screenChoices:
+		return "\n" + m.list.View()
+	case screenInput:
+		return fmt.Sprintf("%s\n\n%s\n\n%s\n", m.title, m.input.View(), helpStyle("enter: submit • esc: skip"))
+	case screenStreaming:
+		return m.streamingView()
+	case screenForm:
+		return m.formView()
+	case screenCompose:
+		return m.composeView()
+	case screenTree:
+		return m.treeView()
+	}
+	return ""
+}
+
+// open switches to a new screen. A screen that's still waiting on the user is
+// answered with a nil result first.
+func (m *appModel) open(s screen, title string, reply chan any) {
+	m.finish(nil)
+	m.screen, m.title, m.reply = s, title, reply
+}
+
+// finish answers the current screen and returns to idle.
+func (m *appModel) finish(result any) {
+	if m.reply != nil {
+		m.reply <- result
+	}
+	m.screen, m.reply = screenIdle, nil
+}
+
+func (m *appModel) interrupt() tea.Cmd {
+	m.app.interrupted.Store(true)
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.finish(nil)
+	return tea.Quit
+}
+
+func (m *appModel) resize() {
+	switch m.screen {
+	case screenSummary, screenDiff:
+		offset := m.viewport.YOffset
+		m.layoutViewport()
+		m.viewport.SetYOffset(offset)
+	case screenChoices:
+		m.list.SetSize(listWidth(m.width), listHeight(len(m.list.Items()), m.height))
+	case screenForm:
+		m.form.resize(m.width)
+	case screenCompose:
+		m.composer.resize(m.width)
+	case screenTree:
+		m.tree.input.Width = max(20, m.width-20)
+	}
+}
+
+func (m *appModel) streamingView() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", m.title, m.spinner.View())
+	// Show the tail of the response so progress is visible without the
+	// output growing past the screen.
+	lines := strings.Split(strings.TrimRight(m.streamed, "\n"), "\n")
+	tail := max(0, len(lines)-streamLines)
+	width := max(10, m.width-4)
+	for _, l := range lines[tail:] {
+		if len(l) > width {
+			l = l[:width-1] + "…"
+		}
+		b.WriteString(streamStyle(l) + "\n")
+	}
+	b.WriteString(helpStyle(fmt.Sprintf("%d bytes received • esc: cancel • ctrl+c: quit", len(m.streamed))))
+	return b.String()
+}
+
+// logWriter prints log lines above the program instead of writing to the
+// terminal directly, which would corrupt the current screen.
+type logWriter struct {
+	app *App
+}
+
+func (w logWriter) Write(b []byte) (int, error) {
+	select {
+	case <-w.app.done:
+		return os.Stderr.Write(b)
+	default:
+	}
+	w.app.println(strings.TrimRight(string(b), "\n"))
+	return len(b), nil
+}
@@ -0,0 +1,256 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	minComposeHeight = 5
+	maxComposeHeight = 14
+)
+
+// Snippet is reusable text the user can insert while composing.
+type Snippet struct {
+	Name string
+	Text string
+}
+
+type composeMode int
+
+const (
+	composeEditing composeMode = iota
+	composePicking
+	composeNaming
+)
+
+type (
+	composeMsg struct {
+		prompt   string
+		history  []string
+		snippets []Snippet
+		reply    chan any
+	}
+
+	// Composed is the result of Compose.
+	Composed struct {
+		// Text is empty if the user skipped the prompt.
+		Text string
+		// Saved are the snippets the user saved while composing, which should
+		// be persisted by the caller.
+		Saved []Snippet
+	}
+
+	composer struct {
+		mode     composeMode
+		area     textarea.Model
+		name     textinput.Model
+		history  []string
+		snippets []Snippet
+		saved    []Snippet
+		// recalled is the index of the history entry being shown, or
+		// len(history) for the draft.
+		recalled int
+		draft    string
+		picked   int
+		status   string
+	}
+)
+
+// Compose asks the user for text that may span several lines, e.g. a change
+// request. history holds earlier entries, oldest first, which can be recalled
+// with ctrl+p and ctrl+n, and snippets can be inserted with ctrl+o.
+func Compose(prompt string, history []string, snippets []Snippet) Composed {
+	return Default().Compose(prompt, history, snippets)
+}
+
+func (a *App) Compose(prompt string, history []string, snippets []Snippet) Composed {
+	reply := make(chan any, 1)
+	composed, _ := a.request(composeMsg{prompt: prompt, history: history, snippets: snippets, reply: reply}, reply).(Composed)
+	a.echo(composed.Text)
+	return composed
+}
+
+func newComposer(msg composeMsg, width int) *composer {
+	c := &composer{history: msg.history, snippets: msg.snippets, recalled: len(msg.history)}
+	c.area = textarea.New()
+	c.area.ShowLineNumbers = false
+	c.area.CharLimit = 0
+	c.area.Placeholder = "Type here. Enter adds a new line."
+	// ctrl+p and ctrl+n recall history instead of moving between lines.
+	c.area.KeyMap.LinePrevious = key.NewBinding(key.WithKeys("up"))
+	c.area.KeyMap.LineNext = key.NewBinding(key.WithKeys("down"))
+	c.area.Focus()
+	c.name = textinput.New()
+	c.name.Prompt = "Snippet name: "
+	c.name.CharLimit = 0
+	c.resize(width)
+	return c
+}
+
+func (c *composer) resize(width int) {
+	c.area.SetWidth(max(20, width-4))
+	c.name.Width = max(20, width-20)
+	c.fit()
+}
+
+// fit grows the text area with its content, up to a limit.
+func (c *composer) fit() {
+	c.area.SetHeight(min(maxComposeHeight, max(minComposeHeight, c.area.LineCount()+1)))
+}
+
+func (c *composer) result(text string) Composed {
+	return Composed{Text: strings.TrimSpace(text), Saved: c.saved}
+}
+
+// recall replaces the text with the history entry delta steps away, keeping
+// the draft so it can be returned to.
+func (c *composer) recall(delta int) {
+	next := c.recalled + delta
+	if next < 0 || next > len(c.history) {
+		return
+	}
+	if c.recalled == len(c.history) {
+		c.draft = c.area.Value()
+	}
+	c.recalled = next
+	if next == len(c.history) {
+		c.area.SetValue(c.draft)
+		c.status = ""
+	} else {
+		c.area.SetValue(c.history[next])
+		c.status = fmt.Sprintf("history %d of %d", len(c.history)-next, len(c.history))
+	}
+	c.fit()
+}
+
+func (m *appModel) updateCompose(msg tea.Msg) (tea.Model, tea.Cmd) {
+	c := m.composer
+	k, isKey := msg.(tea.KeyMsg)
+	switch c.mode {
+	case composePicking:
+		if !isKey {
+			return m, nil
+		}
+		switch k.String() {
+		case "up", "k":
+			c.picked = max(0, c.picked-1)
+		case "down", "j":
+			c.picked = min(len(c.snippets)-1, c.picked+1)
+		case "enter":
+			c.area.InsertString(c.snippets[c.picked].Text)
+			c.fit()
+			c.mode, c.status = composeEditing, "inserted "+c.snippets[c.picked].Name
+		case "esc":
+			c.mode = composeEditing
+		}
+		return m, nil
+	case composeNaming:
+		if isKey {
+			switch k.Type {
+			case tea.KeyEnter:
+				name := strings.TrimSpace(c.name.Value())
+				if name == "" {
+					return m, nil
+				}
+				s := Snippet{Name: name, Text: strings.TrimSpace(c.area.Value())}
+				c.saved = append(c.saved, s)
+				c.snippets = append(c.snippets, s)
+				c.mode, c.status = composeEditing, "saved snippet "+name
+				c.name.Blur()
+				return m, c.area.Focus()
+			case tea.KeyEsc:
+				c.mode = composeEditing
+				c.name.Blur()
+				return m, c.area.Focus()
+			}
+		}
+		var cmd tea.Cmd
+		c.name, cmd = c.name.Update(msg)
+		return m, cmd
+	}
+
+	if isKey {
+		switch k.String() {
+		case "ctrl+s", "alt+enter":
+			m.finish(c.result(c.area.Value()))
+			return m, nil
+		case "esc":
+			m.finish(c.result(""))
+			return m, nil
+		case "ctrl+p":
+			c.recall(-1)
+			return m, nil
+		case "ctrl+n":
+			c.recall(1)
+			return m, nil
+		case "ctrl+o":
+			if len(c.snippets) == 0 {
+				c.status = "no snippets yet, save one with ctrl+r"
+				return m, nil
+			}
+			c.mode, c.picked = composePicking, 0
+			return m, nil
+		case "ctrl+r":
+			if strings.TrimSpace(c.area.Value()) == "" {
+				c.status = "type something to save as a snippet first"
+				return m, nil
+			}
+			c.mode = composeNaming
+			c.name.SetValue("")
+			c.area.Blur()
+			return m, c.name.Focus()
+		}
+	}
+	var cmd tea.Cmd
+	c.area, cmd = c.area.Update(msg)
+	c.fit()
+	return m, cmd
+}
+
+func (m *appModel) composeView() string {
+	c := m.composer
+	var b strings.Builder
+	b.WriteString(m.title + "\n\n")
+	b.WriteString(c.area.View() + "\n")
+	switch c.mode {
+	case composePicking:
+		b.WriteString("\n" + titleStyle.Render("Insert a snippet:") + "\n")
+		// Keep the selected snippet in view when there are more than fit.
+		first := max(0, min(c.picked-maxListHeight/2, len(c.snippets)-maxListHeight))
+		for i := first; i < min(len(c.snippets), first+maxListHeight); i++ {
+			line := c.snippets[i].Name + " " + helpStyle(preview(c.snippets[i].Text, max(10, m.width-len(c.snippets[i].Name)-10)))
+			if i == c.picked {
+				b.WriteString(selectedItemStyle.Render("> "+line) + "\n")
+			} else {
+				b.WriteString(itemStyle.Render(line) + "\n")
+			}
+		}
+		b.WriteString("\n" + helpStyle("↑/↓: select • enter: insert • esc: back"))
+	case composeNaming:
+		b.WriteString("\n" + c.name.View() + "\n\n" + helpStyle("enter: save • esc: back"))
+	default:
+		if c.status != "" {
+			b.WriteString(helpStyle(c.status) + "\n")
+		}
+		b.WriteString("\n" + helpStyle(wrap("ctrl+s: submit • ctrl+p/ctrl+n: history • ctrl+o: insert snippet • ctrl+r: save as snippet • esc: skip", max(20, m.width))))
+	}
+	return b.String()
+}
+
+// preview returns the first line of s, shortened to width.
+func preview(s string, width int) string {
+	line, _, more := strings.Cut(strings.TrimSpace(s), "\n")
+	if r := []rune(line); len(r) > width {
+		line, more = string(r[:width-1]), true
+	}
+	if more {
+		line += "…"
+	}
+	return line
+}
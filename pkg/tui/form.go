@@ -0,0 +1,295 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const maxAreaHeight = 8
+
+// Field is a single value in a form.
+type Field struct {
+	// Key identifies the field to the caller, e.g. a JSON pointer.
+	Key   string
+	Label string
+	// Description is shown under the field while it's focused.
+	Description string
+	Value       string
+	// Options, if set, are the only values the field accepts. They're chosen
+	// with left and right rather than typed.
+	Options []string
+	// Multiline fields are edited in a text area, e.g. for lists with one item
+	// per line.
+	Multiline bool
+	// Error is shown under the field, e.g. when its value didn't validate.
+	Error string
+}
+
+type (
+	formMsg struct {
+		title   string
+		problem string
+		fields  []Field
+		reply   chan any
+	}
+
+	formField struct {
+		Field
+		input textinput.Model
+		area  textarea.Model
+	}
+
+	form struct {
+		problem string
+		fields  []formField
+		focus   int
+	}
+)
+
+// Form asks the user to edit fields, returning them with their new values.
+// problem is shown above the fields, e.g. when the last values didn't
+// validate. It returns false if the user cancelled or the session was
+// interrupted.
+func Form(title, problem string, fields []Field) ([]Field, bool) {
+	return Default().Edit(title, problem, fields)
+}
+
+func (a *App) Edit(title, problem string, fields []Field) ([]Field, bool) {
+	reply := make(chan any, 1)
+	edited, ok := a.request(formMsg{title: title, problem: problem, fields: fields, reply: reply}, reply).([]Field)
+	return edited, ok
+}
+
+func newForm(problem string, fields []Field, width int) *form {
+	f := &form{problem: problem}
+	for _, field := range fields {
+		ff := formField{Field: field}
+		if field.Multiline {
+			ff.area = textarea.New()
+			ff.area.ShowLineNumbers = false
+			ff.area.CharLimit = 0
+			ff.area.Prompt = "┃ "
+			ff.area.SetValue(field.Value)
+			ff.area.Blur()
+		} else {
+			ff.input = textinput.New()
+			ff.input.CharLimit = 0
+			ff.input.Prompt = "> "
+			ff.input.SetValue(field.Value)
+		}
+		f.fields = append(f.fields, ff)
+	}
+	f.resize(width)
+	// Start on the first field with a problem, if any.
+	f.focusField(max(0, slices.IndexFunc(fields, func(field Field) bool { return field.Error != "" })))
+	return f
+}
+
+func (f *form) resize(width int) {
+	for i := range f.fields {
+		ff := &f.fields[i]
+		if ff.Multiline {
+			ff.area.SetWidth(max(20, width-6))
+			ff.area.SetHeight(min(maxAreaHeight, max(3, ff.area.LineCount()+1)))
+		} else {
+			ff.input.Width = max(20, width-8)
+		}
+	}
+}
+
+func (f *form) focusField(i int) tea.Cmd {
+	if len(f.fields) == 0 {
+		return nil
+	}
+	f.focus = (i + len(f.fields)) % len(f.fields)
+	var cmd tea.Cmd
+	for j := range f.fields {
+		ff := &f.fields[j]
+		switch {
+		case ff.Options != nil:
+		case j == f.focus && ff.Multiline:
+			cmd = ff.area.Focus()
+		case j == f.focus:
+			cmd = ff.input.Focus()
+		case ff.Multiline:
+			ff.area.Blur()
+		default:
+			ff.input.Blur()
+		}
+	}
+	return cmd
+}
+
+// values returns the fields with their current values.
+func (f *form) values() []Field {
+	fields := make([]Field, len(f.fields))
+	for i, ff := range f.fields {
+		fields[i] = ff.Field
+		switch {
+		case ff.Options != nil:
+		case ff.Multiline:
+			fields[i].Value = ff.area.Value()
+		default:
+			fields[i].Value = ff.input.Value()
+		}
+		fields[i].Error = ""
+	}
+	return fields
+}
+
+// cycle moves an options field to the next or previous option.
+func (ff *formField) cycle(delta int) {
+	i := slices.Index(ff.Options, ff.Value)
+	if i < 0 {
+		i = 0
+		if delta > 0 {
+			delta = 0
+		}
+	}
+	ff.Value = ff.Options[(i+delta+len(ff.Options))%len(ff.Options)]
+}
+
+func (m *appModel) updateForm(msg tea.Msg) (tea.Model, tea.Cmd) {
+	f := m.form
+	if len(f.fields) == 0 {
+		m.finish(f.values())
+		return m, nil
+	}
+	current := &f.fields[f.focus]
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "ctrl+s":
+			m.finish(f.values())
+			return m, nil
+		case "esc":
+			m.finish(nil)
+			return m, nil
+		case "tab":
+			return m, f.focusField(f.focus + 1)
+		case "shift+tab":
+			return m, f.focusField(f.focus - 1)
+		}
+		if !current.Multiline {
+			switch key.String() {
+			case "down", "enter":
+				return m, f.focusField(f.focus + 1)
+			case "up":
+				return m, f.focusField(f.focus - 1)
+			}
+		}
+		if current.Options != nil {
+			switch key.String() {
+			case "left", "h":
+				current.cycle(-1)
+			case "right", "l", " ":
+				current.cycle(1)
+			}
+			return m, nil
+		}
+	}
+	var cmd tea.Cmd
+	if current.Multiline {
+		current.area, cmd = current.area.Update(msg)
+		current.area.SetHeight(min(maxAreaHeight, max(3, current.area.LineCount()+1)))
+	} else {
+		current.input, cmd = current.input.Update(msg)
+	}
+	return m, cmd
+}
+
+func (m *appModel) formView() string {
+	f := m.form
+	header := titleBarStyle.Render(m.title) + "\n"
+	if f.problem != "" {
+		header += "\n" + errorStyle(f.problem) + "\n"
+	}
+	footer := helpStyle("tab/↑/↓: move • ←/→: change option • ctrl+s: save • esc: cancel")
+	if len(f.fields) == 0 {
+		return header + "\n" + footer
+	}
+
+	// Render each field separately so that the form can scroll to keep the
+	// focused one on screen.
+	blocks := make([]string, len(f.fields))
+	for i, ff := range f.fields {
+		var b strings.Builder
+		label := itemStyle.Render(ff.Label)
+		if i == f.focus {
+			label = selectedItemStyle.Render("› " + ff.Label)
+		}
+		b.WriteString("\n" + label + "\n")
+		switch {
+		case ff.Options != nil:
+			var options []string
+			for _, o := range ff.Options {
+				if o == ff.Value {
+					o = selectedItemStyle.UnsetPaddingLeft().Render("[" + o + "]")
+				}
+				options = append(options, o)
+			}
+			b.WriteString(itemStyle.Render(strings.Join(options, "  ")) + "\n")
+		case ff.Multiline:
+			b.WriteString(itemStyle.Render(ff.area.View()) + "\n")
+		default:
+			b.WriteString(itemStyle.Render(ff.input.View()) + "\n")
+		}
+		if ff.Error != "" {
+			b.WriteString(itemStyle.Render(errorStyle(ff.Error)) + "\n")
+		}
+		if i == f.focus && ff.Description != "" {
+			b.WriteString(itemStyle.Render(helpStyle(wrap(ff.Description, max(20, m.width-8)))) + "\n")
+		}
+		blocks[i] = b.String()
+	}
+
+	room := m.height - strings.Count(header, "\n") - 3
+	first, last := f.focus, f.focus+1
+	used := strings.Count(blocks[f.focus], "\n")
+	for {
+		grew := false
+		if last < len(blocks) && used+strings.Count(blocks[last], "\n") <= room {
+			used += strings.Count(blocks[last], "\n")
+			last++
+			grew = true
+		}
+		if first > 0 && used+strings.Count(blocks[first-1], "\n") <= room {
+			first--
+			used += strings.Count(blocks[first], "\n")
+			grew = true
+		}
+		if !grew {
+			break
+		}
+	}
+
+	position := ""
+	if first > 0 || last < len(blocks) {
+		position = fmt.Sprintf("field %d of %d • ", f.focus+1, len(f.fields))
+	}
+	return header + strings.Join(blocks[first:last], "") + "\n" + helpStyle(position) + footer
+}
+
+// wrap breaks s into lines of at most width characters at spaces.
+func wrap(s string, width int) string {
+	var b strings.Builder
+	line := 0
+	for i, word := range strings.Fields(s) {
+		if i > 0 {
+			if line+1+len(word) > width {
+				b.WriteString("\n")
+				line = 0
+			} else {
+				b.WriteString(" ")
+				line++
+			}
+		}
+		b.WriteString(word)
+		line += len(word)
+	}
+	return b.String()
+}
@@ -1,96 +0,0 @@
-package tui
-
-import (
-	"fmt"
-	"os"
-
-	"github.com/charmbracelet/bubbles/viewport"
-	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/glamour"
-	"github.com/charmbracelet/lipgloss"
-)
-
-var helpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render
-
-type InfoView struct {
-	viewport viewport.Model
-}
-
-func newInfoView(content string) (*InfoView, error) {
-	const width = 78
-
-	vp := viewport.New(width, 20)
-	vp.Style = lipgloss.NewStyle().
-		BorderStyle(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("62")).
-		PaddingRight(2)
-
-	// We need to adjust the width of the glamour render from our main width
-	// to account for a few things:
-	//
-	//  * The viewport border width
-	//  * The viewport padding
-	//  * The viewport margins
-	//  * The gutter glamour applies to the left side of the content
-	//
-	const glamourGutter = 2
-	glamourRenderWidth := width - vp.Style.GetHorizontalFrameSize() - glamourGutter
-
-	renderer, err := glamour.NewTermRenderer(
-		glamour.WithAutoStyle(),
-		glamour.WithWordWrap(glamourRenderWidth),
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	str, err := renderer.Render(content)
-	if err != nil {
-		return nil, err
-	}
-
-	vp.SetContent(str)
-
-	return &InfoView{
-		viewport: vp,
-	}, nil
-}
-
-func (e InfoView) Init() tea.Cmd {
-	return nil
-}
-
-func (e InfoView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "q", "ctrl+c", "esc":
-			return e, tea.Quit
-		default:
-			var cmd tea.Cmd
-			e.viewport, cmd = e.viewport.Update(msg)
-			return e, cmd
-		}
-	default:
-		return e, nil
-	}
-}
-
-func (e InfoView) View() string {
-	return e.viewport.View() + e.helpView()
-}
-
-func (e InfoView) helpView() string {
-	return helpStyle("\n  ↑/↓: Navigate • q: Quit\n")
-}
-
-func MarkdownView(content string) {
-	model, err := newInfoView(content)
-	if err != nil {
-		fmt.Println("Could not initialize Bubble Tea model:", err)
-		os.Exit(1)
-	}
-
-	p := tea.NewProgram(model)
-	p.Run()
-}
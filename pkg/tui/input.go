@@ -1,78 +1,43 @@
 package tui
 
-// A simple program demonstrating the text input component from the Bubbles
-// component library.
-
 import (
-	"fmt"
-
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// Input asks the user for a line of text. It returns an empty string if they
+// skip it or the session was interrupted.
 func Input(prompt string) string {
-	m := initialModel(prompt)
-	p := tea.NewProgram(&m)
-	p.Run()
-	return m.textInput.Value()
+	return Default().Ask(prompt)
 }
 
-type (
-	errMsg error
-)
-
-type inputModel struct {
-	prompt    string
-	textInput textinput.Model
-	response  string
-	err       error
+func (a *App) Ask(prompt string) string {
+	reply := make(chan any, 1)
+	value, _ := a.request(inputMsg{prompt: prompt, reply: reply}, reply).(string)
+	a.echo(value)
+	return value
 }
 
-func initialModel(prompt string) inputModel {
+func newInput(width int) textinput.Model {
 	ti := textinput.New()
 	ti.Focus()
-	ti.CharLimit = 240
-	ti.Width = 0 // unlimited
-
-	return inputModel{
-		prompt:    prompt,
-		textInput: ti,
-		err:       nil,
-	}
-}
-
-func (m inputModel) Init() tea.Cmd {
-	return textinput.Blink
+	ti.CharLimit = 0
+	ti.Width = max(20, width-4)
+	return ti
 }
 
-func (m *inputModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var cmd tea.Cmd
-
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.Type {
+func (m *appModel) updateInput(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.Type {
 		case tea.KeyEnter:
-			m.response = msg.String()
-			return m, tea.Quit
-		case tea.KeyCtrlC, tea.KeyEsc:
-			return m, tea.Quit
+			m.finish(m.input.Value())
+			return m, nil
+		case tea.KeyEsc:
+			m.finish(nil)
+			return m, nil
 		}
-
-	// We handle errors just like any other message
-	case errMsg:
-		m.err = msg
-		return m, nil
 	}
-
-	m.textInput, cmd = m.textInput.Update(msg)
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
 	return m, cmd
 }
-
-func (m inputModel) View() string {
-	return fmt.Sprintf(
-		"%s\n\n%s\n\n%s",
-		m.prompt,
-		m.textInput.View(),
-		"(esc to quit)",
-	) + "\n"
-}
@@ -7,19 +7,10 @@ import (
 
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 )
 
-const listHeight = 14
-
-var (
-	titleStyle        = lipgloss.NewStyle().MarginLeft(2)
-	itemStyle         = lipgloss.NewStyle().PaddingLeft(4)
-	selectedItemStyle = lipgloss.NewStyle().PaddingLeft(2).Foreground(lipgloss.Color("170"))
-	paginationStyle   = list.DefaultStyles().PaginationStyle.PaddingLeft(4)
-	listStyle         = list.DefaultStyles().HelpStyle.PaddingLeft(4).PaddingBottom(1)
-	quitTextStyle     = lipgloss.NewStyle().Margin(1, 0, 2, 4)
-)
+// maxListHeight caps the height of choice lists, which paginate beyond it.
+const maxListHeight = 14
 
 type item string
 
@@ -48,72 +39,58 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 	fmt.Fprint(w, fn(str))
 }
 
-type model struct {
-	list     list.Model
-	choice   string
-	quitting bool
-}
-
-func (m model) Init() tea.Cmd {
-	return nil
+func List(items []string) string {
+	return ListWithTitle("What do you want to do next?", items)
 }
 
-func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	switch msg := msg.(type) {
-	case tea.WindowSizeMsg:
-		m.list.SetWidth(msg.Width)
-		return m, nil
-
-	case tea.KeyMsg:
-		switch keypress := msg.String(); keypress {
-		case "q", "ctrl+c":
-			m.quitting = true
-			return m, tea.Quit
-
-		case "enter":
-			i, ok := m.list.SelectedItem().(item)
-			if ok {
-				m.choice = string(i)
-			}
-			return m, tea.Quit
-		}
-	}
-
-	var cmd tea.Cmd
-	m.list, cmd = m.list.Update(msg)
-	return m, cmd
+// ListWithTitle asks the user to choose one of items, returning an empty
+// string if the session was interrupted.
+func ListWithTitle(title string, items []string) string {
+	return Default().Choose(title, items)
 }
 
-func (m model) View() string {
-	if m.choice != "" {
-		return quitTextStyle.Render("> " + m.choice)
-	}
-	if m.quitting {
-		return quitTextStyle.Render("Exiting...")
-	}
-	return "\n" + m.list.View()
+func (a *App) Choose(title string, items []string) string {
+	reply := make(chan any, 1)
+	choice, _ := a.request(chooseMsg{title: title, items: items, reply: reply}, reply).(string)
+	a.echo(choice)
+	return choice
 }
 
-func List(items []string) string {
+func newList(title string, items []string, width, height int) list.Model {
 	var choices []list.Item
 	for _, i := range items {
 		choices = append(choices, item(i))
 	}
-
-	const defaultWidth = 20
-
-	l := list.New(choices, itemDelegate{}, defaultWidth, listHeight)
-	l.Title = "What do you want to do next?"
+	l := list.New(choices, itemDelegate{}, listWidth(width), listHeight(len(items), height))
+	l.Title = title
 	l.SetShowStatusBar(false)
 	l.SetFilteringEnabled(false)
+	// The app handles quitting, so a stray q doesn't end the session.
+	l.KeyMap.Quit.SetEnabled(false)
+	l.KeyMap.ForceQuit.SetEnabled(false)
 	l.Styles.Title = titleStyle
 	l.Styles.PaginationStyle = paginationStyle
 	l.Styles.HelpStyle = listStyle
+	return l
+}
 
-	m := model{list: l}
+func listWidth(width int) int {
+	return max(20, width)
+}
 
-	p := tea.NewProgram(&m)
-	p.Run()
+func listHeight(items, height int) int {
+	// Leave room for the title, pagination and help.
+	return max(6, min(maxListHeight, items+6, height-2))
+}
 
-	return m.choice
+func (m *appModel) updateList(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok && key.String() == "enter" {
+		if i, ok := m.list.SelectedItem().(item); ok {
+			m.finish(string(i))
+			return m, nil
+		}
+	}
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	return m, cmd
 }
@@ -0,0 +1,227 @@
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// endOfText ends multi-line answers in the plain renderer.
+const endOfText = "."
+
+// plain renders screens as lines of text and reads answers from stdin, for
+// when stdout isn't a terminal, e.g. when it's piped to a file or run in CI.
+// Views are printed without waiting, and end of input interrupts the session
+// the way ctrl+c does.
+type plain struct {
+	in  *bufio.Reader
+	out io.Writer
+	// mu serializes output, since log lines and streams write concurrently.
+	mu sync.Mutex
+	// statuses are the stage statuses last printed, so the timeline is only
+	// printed when it changes.
+	statuses map[string]StageStatus
+}
+
+func startPlain() *App {
+	a := &App{
+		done:  make(chan struct{}),
+		plain: &plain{in: bufio.NewReader(os.Stdin), out: os.Stdout, statuses: map[string]StageStatus{}},
+	}
+	return a
+}
+
+func (p *plain) println(s string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintln(p.out, s)
+}
+
+// readLine reads a line from stdin, returning false at the end of input.
+func (p *plain) readLine(prompt string) (string, bool) {
+	p.mu.Lock()
+	fmt.Fprint(p.out, prompt)
+	p.mu.Unlock()
+	line, err := p.in.ReadString('\n')
+	if err != nil && line == "" {
+		p.println("")
+		return "", false
+	}
+	return strings.TrimRight(line, "\r\n"), true
+}
+
+// readLines reads lines until one holding only endOfText, returning false
+// at the end of input.
+func (p *plain) readLines() (string, bool) {
+	var lines []string
+	for {
+		line, ok := p.readLine("| ")
+		if !ok {
+			return "", false
+		}
+		if strings.TrimSpace(line) == endOfText {
+			return strings.Join(lines, "\n"), true
+		}
+		lines = append(lines, line)
+	}
+}
+
+// plainRequest shows a screen and waits for the answer, returning nil if the
+// input ended.
+func (a *App) plainRequest(msg any) any {
+	p := a.plain
+	var (
+		result any
+		ok     = true
+	)
+	switch msg := msg.(type) {
+	case showMsg:
+		if msg.title != "" {
+			p.println("== " + msg.title + " ==")
+		}
+		p.println(strings.TrimRight(msg.content, "\n") + "\n")
+	case chooseMsg:
+		result, ok = p.choose(msg)
+	case inputMsg:
+		p.println(msg.prompt)
+		result, ok = p.readLine("> ")
+	case composeMsg:
+		p.println(msg.prompt)
+		p.println(fmt.Sprintf("(end with a line holding only %q; end straight away to skip)", endOfText))
+		var text string
+		text, ok = p.readLines()
+		result = Composed{Text: strings.TrimSpace(text)}
+	case formMsg:
+		result, ok = p.form(msg)
+	case treeMsg:
+		p.tree(msg)
+	}
+	if !ok {
+		a.interrupted.Store(true)
+		return nil
+	}
+	return result
+}
+
+func (p *plain) choose(msg chooseMsg) (any, bool) {
+	p.println(msg.title)
+	for i, item := range msg.items {
+		p.println(fmt.Sprintf("  %d. %s", i+1, item))
+	}
+	for {
+		answer, ok := p.readLine("> ")
+		if !ok {
+			return nil, false
+		}
+		answer = strings.TrimSpace(answer)
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(msg.items) {
+			return msg.items[n-1], true
+		}
+		if i := slices.IndexFunc(msg.items, func(item string) bool { return strings.EqualFold(item, answer) }); i >= 0 {
+			return msg.items[i], true
+		}
+		p.println(fmt.Sprintf("Enter a number from 1 to %d.", len(msg.items)))
+	}
+}
+
+// form asks for each field in turn. Empty answers keep the current value.
+func (p *plain) form(msg formMsg) (any, bool) {
+	p.println("== " + msg.title + " ==")
+	if msg.problem != "" {
+		p.println(msg.problem)
+	}
+	fields := slices.Clone(msg.fields)
+	for i, f := range fields {
+		p.println("")
+		p.println(f.Label)
+		if f.Description != "" {
+			p.println("  " + f.Description)
+		}
+		if f.Error != "" {
+			p.println("  ! " + f.Error)
+		}
+		if f.Multiline {
+			for _, l := range strings.Split(f.Value, "\n") {
+				p.println("  " + l)
+			}
+			p.println(fmt.Sprintf("(one item per line, ending with %q; end straight away to keep them)", endOfText))
+			value, ok := p.readLines()
+			if !ok {
+				return nil, false
+			}
+			if value != "" {
+				fields[i].Value = value
+			}
+			continue
+		}
+		prompt := fmt.Sprintf("[%s] ", f.Value)
+		if len(f.Options) > 0 {
+			prompt = fmt.Sprintf("(%s) %s", strings.Join(f.Options, "/"), prompt)
+		}
+		for {
+			value, ok := p.readLine(prompt)
+			if !ok {
+				return nil, false
+			}
+			value = strings.TrimSpace(value)
+			if value != "" && len(f.Options) > 0 && !slices.Contains(f.Options, value) {
+				p.println("Enter one of " + strings.Join(f.Options, ", ") + ".")
+				continue
+			}
+			if value != "" {
+				fields[i].Value = value
+			}
+			break
+		}
+	}
+	return fields, true
+}
+
+// tree lists the files. They can't be edited in the plain renderer, so it
+// always returns as if the tree was closed without saving.
+func (p *plain) tree(msg treeMsg) {
+	p.println("== " + msg.title + " ==")
+	if msg.problem != "" {
+		p.println(msg.problem)
+	}
+	for _, n := range msg.nodes {
+		line := fmt.Sprintf("  %s (%s)", n.Path, n.Action)
+		if n.Status != "" {
+			line += " [" + n.Status + "]"
+		}
+		p.println(line)
+		if n.Purpose != "" {
+			p.println("      " + n.Purpose)
+		}
+		if len(n.DependsOn) > 0 {
+			p.println("      depends on " + strings.Join(n.DependsOn, ", "))
+		}
+	}
+	if msg.editable {
+		p.println("(the planned files can only be edited in a terminal)")
+	}
+	p.println("")
+}
+
+// timeline prints the stages whose status changed since it was last called.
+func (p *plain) timeline(stages []TimelineStage) {
+	for _, s := range stages {
+		if p.statuses[s.Name] == s.Status {
+			continue
+		}
+		p.statuses[s.Name] = s.Status
+		if s.Status == StagePending {
+			continue
+		}
+		line := fmt.Sprintf("%s %s: %s", statusIcon(s.Status), s.Name, s.Status)
+		if s.Status == StageRunning && s.Iterations > 1 {
+			line += fmt.Sprintf(" (iteration %d)", s.Iterations)
+		}
+		p.println(line)
+	}
+}
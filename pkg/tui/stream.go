@@ -0,0 +1,75 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// streamLines is how many lines of a streaming response are shown.
+const streamLines = 8
+
+// Stream is the streaming screen, shown while the model generates a response.
+type Stream struct {
+	app  *App
+	once sync.Once
+	// received counts the bytes written, which is all the plain renderer
+	// shows of the response.
+	received atomic.Int64
+}
+
+// StartStream shows the streaming screen until Stop is called. Pressing esc
+// calls cancel, which should abort the request.
+func StartStream(ctx context.Context, cancel context.CancelFunc, title string) *Stream {
+	return Default().Stream(ctx, cancel, title)
+}
+
+func (a *App) Stream(ctx context.Context, cancel context.CancelFunc, title string) *Stream {
+	s := &Stream{app: a}
+	if a.plain != nil {
+		a.plain.println(title + " ...")
+	} else if !a.interrupted.Load() {
+		a.program.Send(streamMsg{title: title, cancel: cancel})
+	}
+	go func() {
+		<-ctx.Done()
+		s.Stop()
+	}()
+	return s
+}
+
+// Write appends a chunk of the response to the screen.
+func (s *Stream) Write(chunk string) {
+	s.received.Add(int64(len(chunk)))
+	if s.app.plain != nil {
+		return
+	}
+	select {
+	case <-s.app.done:
+	default:
+		s.app.program.Send(chunkMsg(chunk))
+	}
+}
+
+// Stop returns to idle once the response is complete. It's safe to call more
+// than once.
+func (s *Stream) Stop() {
+	s.once.Do(func() {
+		reply := make(chan any, 1)
+		select {
+		case <-s.app.done:
+			return
+		default:
+		}
+		if s.app.plain != nil {
+			s.app.plain.println(fmt.Sprintf("%d bytes received", s.received.Load()))
+			return
+		}
+		s.app.program.Send(stopStreamMsg{reply: reply})
+		select {
+		case <-reply:
+		case <-s.app.done:
+		}
+	})
+}
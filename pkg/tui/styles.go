@@ -0,0 +1,62 @@
+package tui
+
+import (
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/zachwalton/devoid/pkg/config"
+)
+
+// defaultTheme fills in any colors the configured theme leaves out.
+var defaultTheme = config.Theme{
+	Accent:   "62",
+	Title:    "230",
+	Selected: "170",
+	Muted:    "241",
+	Subtle:   "245",
+	Error:    "203",
+}
+
+var (
+	borderColor lipgloss.Color
+
+	helpStyle         func(...string) string
+	errorStyle        func(...string) string
+	streamStyle       func(...string) string
+	titleBarStyle     lipgloss.Style
+	titleStyle        = lipgloss.NewStyle().MarginLeft(2)
+	itemStyle         = lipgloss.NewStyle().PaddingLeft(4)
+	selectedItemStyle lipgloss.Style
+	paginationStyle   = list.DefaultStyles().PaginationStyle.PaddingLeft(4)
+	listStyle         = list.DefaultStyles().HelpStyle.PaddingLeft(4).PaddingBottom(1)
+	quitTextStyle     = lipgloss.NewStyle().MarginLeft(2)
+	sidebarStyle      lipgloss.Style
+)
+
+func init() {
+	setTheme(defaultTheme)
+}
+
+// setTheme builds the styles from t, using the default for any color it
+// leaves out.
+func setTheme(t config.Theme) {
+	color := func(c, fallback string) lipgloss.Color {
+		if c == "" {
+			return lipgloss.Color(fallback)
+		}
+		return lipgloss.Color(c)
+	}
+	borderColor = color(t.Accent, defaultTheme.Accent)
+	helpStyle = lipgloss.NewStyle().Foreground(color(t.Muted, defaultTheme.Muted)).Render
+	errorStyle = lipgloss.NewStyle().Foreground(color(t.Error, defaultTheme.Error)).Render
+	streamStyle = lipgloss.NewStyle().Foreground(color(t.Subtle, defaultTheme.Subtle)).Render
+	titleBarStyle = lipgloss.NewStyle().Bold(true).Foreground(color(t.Title, defaultTheme.Title)).Background(borderColor).Padding(0, 1)
+	selectedItemStyle = lipgloss.NewStyle().PaddingLeft(2).Foreground(color(t.Selected, defaultTheme.Selected))
+	sidebarStyle = lipgloss.NewStyle().Border(lipgloss.NormalBorder(), false, true, false, false).BorderForeground(borderColor)
+}
+
+// colorProfile is the terminal's color profile, which log output keeps even
+// though it's written through the app rather than to the terminal.
+func colorProfile() termenv.Profile {
+	return lipgloss.ColorProfile()
+}
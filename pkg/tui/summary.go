@@ -0,0 +1,110 @@
+package tui
+
+import (
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	defaultWidth  = 80
+	defaultHeight = 24
+	// maxViewportWidth keeps Markdown readable on wide terminals.
+	maxViewportWidth = 100
+	// glamourGutter is the margin glamour applies to the left of its output.
+	glamourGutter = 2
+)
+
+// MarkdownView shows rendered Markdown in a scrollable view until the user
+// dismisses it.
+func MarkdownView(content string) {
+	Default().Show(content)
+}
+
+// DiffView shows a titled, scrollable view of Markdown containing diffs until
+// the user dismisses it.
+func DiffView(title, content string) {
+	Default().ShowDiff(title, content)
+}
+
+func (a *App) Show(content string) {
+	reply := make(chan any, 1)
+	a.request(showMsg{screen: screenSummary, content: content, reply: reply}, reply)
+}
+
+func (a *App) ShowDiff(title, content string) {
+	reply := make(chan any, 1)
+	a.request(showMsg{screen: screenDiff, title: title, content: content, reply: reply}, reply)
+}
+
+// markdownStyle is the glamour style from the theme, or the one matching the
+// terminal's background.
+var markdownStyle = "dark"
+
+func detectMarkdownStyle() {
+	switch {
+	case ui.Theme.Markdown != "":
+		markdownStyle = ui.Theme.Markdown
+	case ui.NoColor:
+		markdownStyle = "notty"
+	case !lipgloss.HasDarkBackground():
+		markdownStyle = "light"
+	}
+}
+
+// layoutViewport renders the current content into a viewport sized to the
+// terminal, and no taller than the content needs.
+func (m *appModel) layoutViewport() {
+	vp := viewport.New(min(m.width, maxViewportWidth), 0)
+	vp.Style = lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(borderColor).
+		PaddingRight(2)
+	rendered := renderMarkdown(m.content, vp)
+	// Leave room for the title bar and help below the viewport.
+	available := max(5, m.height-4)
+	vp.Height = min(available, lipgloss.Height(rendered)+vp.Style.GetVerticalFrameSize())
+	vp.SetContent(rendered)
+	m.viewport = vp
+}
+
+// renderMarkdown renders content to fit inside vp, accounting for its border,
+// padding and glamour's own gutter.
+func renderMarkdown(content string, vp viewport.Model) string {
+	width := vp.Width - vp.Style.GetHorizontalFrameSize() - glamourGutter
+	renderer, err := glamour.NewTermRenderer(
+		glamour.WithStylePath(markdownStyle),
+		glamour.WithColorProfile(colorProfile()),
+		glamour.WithWordWrap(max(20, width)),
+	)
+	if err != nil {
+		return content
+	}
+	str, err := renderer.Render(content)
+	if err != nil {
+		return content
+	}
+	return str
+}
+
+func (m *appModel) updateViewport(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "q", "esc", "enter":
+			m.finish(nil)
+			return m, nil
+		}
+	}
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m *appModel) viewportView() string {
+	header := ""
+	if m.screen == screenDiff {
+		header = titleBarStyle.Render(m.title) + "\n"
+	}
+	return header + m.viewport.View() + helpStyle("\n  ↑/↓: Navigate • q: Continue\n")
+}
@@ -0,0 +1,140 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// sidebarWidth is the width of the timeline, including its border.
+	sidebarWidth = 26
+	// sidebarMinWidth is the narrowest terminal the timeline is shown beside
+	// the screen in. Narrower terminals get a single line above it instead.
+	sidebarMinWidth = 100
+)
+
+type StageStatus string
+
+const (
+	StagePending  StageStatus = "pending"
+	StageRunning  StageStatus = "running"
+	StageAccepted StageStatus = "accepted"
+	StageFailed   StageStatus = "failed"
+)
+
+// TimelineStage is a stage's progress as shown in the timeline.
+type TimelineStage struct {
+	Name       string
+	Status     StageStatus
+	Iterations int
+	// Started is when the stage started running, and Duration how long it
+	// took once it's accepted or failed.
+	Started  time.Time
+	Duration time.Duration
+}
+
+type timelineMsg []TimelineStage
+
+// Elapsed returns how long the stage has been running, or took to run.
+func (s TimelineStage) Elapsed() time.Duration {
+	if s.Status == StageRunning && !s.Started.IsZero() {
+		return time.Since(s.Started)
+	}
+	return s.Duration
+}
+
+// SetTimeline shows the progress of each stage alongside every screen.
+func SetTimeline(stages []TimelineStage) {
+	Default().SetTimeline(stages)
+}
+
+func (a *App) SetTimeline(stages []TimelineStage) {
+	select {
+	case <-a.done:
+		return
+	default:
+	}
+	if a.plain != nil {
+		a.plain.timeline(stages)
+		return
+	}
+	a.program.Send(timelineMsg(append([]TimelineStage(nil), stages...)))
+}
+
+func (m *appModel) sidebar() bool {
+	return m.termWidth >= sidebarMinWidth
+}
+
+// layout sizes the screen to the space the timeline leaves.
+func (m *appModel) layout() {
+	m.width, m.height = m.termWidth, m.termHeight
+	if len(m.timeline) > 0 {
+		if m.sidebar() {
+			m.width -= sidebarWidth
+		} else {
+			m.height -= 2
+		}
+	}
+	m.resize()
+}
+
+func (m *appModel) withTimeline(view string) string {
+	if len(m.timeline) == 0 || m.screen == screenIdle {
+		return view
+	}
+	if !m.sidebar() {
+		var steps []string
+		for _, s := range m.timeline {
+			step := statusIcon(s.Status) + " " + s.Name
+			if s.Iterations > 1 {
+				step += fmt.Sprintf(" (%d)", s.Iterations)
+			}
+			steps = append(steps, step)
+		}
+		return helpStyle(strings.Join(steps, " › ")) + "\n\n" + view
+	}
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Bold(true).Render("Stages") + "\n\n")
+	for _, s := range m.timeline {
+		name := statusIcon(s.Status) + " " + s.Name
+		if s.Status == StageRunning {
+			name = selectedItemStyle.UnsetPaddingLeft().Render(name)
+		}
+		b.WriteString(" " + name + "\n")
+		var details []string
+		if s.Iterations > 0 {
+			details = append(details, plural(s.Iterations, "iteration"))
+		}
+		if d := s.Elapsed(); d > 0 {
+			details = append(details, d.Round(time.Second).String())
+		}
+		if len(details) > 0 {
+			b.WriteString("   " + helpStyle(strings.Join(details, " · ")) + "\n")
+		}
+	}
+	sidebar := sidebarStyle.Width(sidebarWidth - 2).Height(max(1, lipgloss.Height(view)-1)).Render(b.String())
+	return lipgloss.JoinHorizontal(lipgloss.Top, sidebar, " ", view)
+}
+
+func statusIcon(s StageStatus) string {
+	switch s {
+	case StageRunning:
+		return "●"
+	case StageAccepted:
+		return "✔"
+	case StageFailed:
+		return "✘"
+	}
+	return "○"
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, word)
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
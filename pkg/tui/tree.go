@@ -0,0 +1,466 @@
+package tui
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// treeDetailLines is the room kept under the tree for the selected node's
+// details.
+const treeDetailLines = 7
+
+// TreeNode is a file in the tree. Directories are implied by the paths.
+type TreeNode struct {
+	Path      string
+	Purpose   string
+	Action    string
+	DependsOn []string
+	// Status describes how far along the file is, e.g. whether it has been
+	// generated yet.
+	Status string
+}
+
+type treeMode int
+
+const (
+	treeBrowsing treeMode = iota
+	treeAdding
+	treeDescribing
+	treeRenaming
+)
+
+type (
+	treeMsg struct {
+		title    string
+		problem  string
+		nodes    []TreeNode
+		editable bool
+		reply    chan any
+	}
+
+	treeRow struct {
+		path  string
+		depth int
+		// node is the index of the file in the tree's nodes, or -1 for a
+		// directory.
+		node int
+	}
+
+	tree struct {
+		nodes     []TreeNode
+		editable  bool
+		problem   string
+		status    string
+		collapsed map[string]bool
+		rows      []treeRow
+		cursor    int
+		mode      treeMode
+		input     textinput.Model
+		// adding is the file being added while its purpose is entered.
+		adding TreeNode
+	}
+)
+
+// Tree shows files in a collapsible tree with the details of the selected
+// one. If editable is set, files can be added, removed and renamed; the edited
+// files are returned when the user saves. problem is shown above the tree,
+// e.g. when the last edits didn't validate. It returns false if the user
+// closed the tree without saving or the session was interrupted.
+func Tree(title, problem string, nodes []TreeNode, editable bool) ([]TreeNode, bool) {
+	return Default().Tree(title, problem, nodes, editable)
+}
+
+func (a *App) Tree(title, problem string, nodes []TreeNode, editable bool) ([]TreeNode, bool) {
+	reply := make(chan any, 1)
+	edited, ok := a.request(treeMsg{title: title, problem: problem, nodes: nodes, editable: editable, reply: reply}, reply).([]TreeNode)
+	return edited, ok
+}
+
+func newTree(msg treeMsg, width int) *tree {
+	t := &tree{
+		nodes:     slices.Clone(msg.nodes),
+		editable:  msg.editable,
+		problem:   msg.problem,
+		collapsed: map[string]bool{},
+	}
+	t.input = textinput.New()
+	t.input.CharLimit = 0
+	t.input.Width = max(20, width-20)
+	t.build()
+	return t
+}
+
+// build lays out the visible rows, with directories before files and both
+// sorted by name.
+func (t *tree) build() {
+	type dir struct {
+		dirs  map[string]*dir
+		files []int
+	}
+	root := &dir{dirs: map[string]*dir{}}
+	for i, n := range t.nodes {
+		d := root
+		parts := strings.Split(n.Path, "/")
+		for _, p := range parts[:len(parts)-1] {
+			if d.dirs[p] == nil {
+				d.dirs[p] = &dir{dirs: map[string]*dir{}}
+			}
+			d = d.dirs[p]
+		}
+		d.files = append(d.files, i)
+	}
+
+	selected := ""
+	if t.cursor < len(t.rows) {
+		selected = t.rows[t.cursor].path
+	}
+	t.rows = nil
+	var walk func(d *dir, prefix string, depth int)
+	walk = func(d *dir, prefix string, depth int) {
+		names := make([]string, 0, len(d.dirs))
+		for name := range d.dirs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			p := path.Join(prefix, name)
+			t.rows = append(t.rows, treeRow{path: p, depth: depth, node: -1})
+			if !t.collapsed[p] {
+				walk(d.dirs[name], p, depth+1)
+			}
+		}
+		sort.Slice(d.files, func(i, j int) bool { return t.nodes[d.files[i]].Path < t.nodes[d.files[j]].Path })
+		for _, i := range d.files {
+			t.rows = append(t.rows, treeRow{path: t.nodes[i].Path, depth: depth, node: i})
+		}
+	}
+	walk(root, "", 0)
+
+	// Keep the same row selected when rows come and go around it.
+	t.cursor = min(t.cursor, max(0, len(t.rows)-1))
+	for i, r := range t.rows {
+		if r.path == selected {
+			t.cursor = i
+		}
+	}
+}
+
+func (t *tree) selected() (treeRow, bool) {
+	if t.cursor >= len(t.rows) {
+		return treeRow{}, false
+	}
+	return t.rows[t.cursor], true
+}
+
+// dir returns the directory new files are added to: the selected directory,
+// or the directory of the selected file.
+func (t *tree) dir() string {
+	r, ok := t.selected()
+	switch {
+	case !ok:
+		return ""
+	case r.node < 0:
+		return r.path + "/"
+	case strings.Contains(r.path, "/"):
+		return path.Dir(r.path) + "/"
+	}
+	return ""
+}
+
+func (t *tree) prompt(mode treeMode, prompt, value string) tea.Cmd {
+	t.mode = mode
+	t.input.Prompt = prompt
+	t.input.SetValue(value)
+	t.input.CursorEnd()
+	return t.input.Focus()
+}
+
+// remove deletes the file or every file in the directory at p, along with
+// any dependencies on them.
+func (t *tree) remove(p string) int {
+	gone := func(q string) bool { return q == p || strings.HasPrefix(q, p+"/") }
+	before := len(t.nodes)
+	t.nodes = slices.DeleteFunc(t.nodes, func(n TreeNode) bool { return gone(n.Path) })
+	for i := range t.nodes {
+		t.nodes[i].DependsOn = slices.DeleteFunc(slices.Clone(t.nodes[i].DependsOn), gone)
+	}
+	return before - len(t.nodes)
+}
+
+// rename moves the file or directory at from to to, updating dependencies on
+// the files it contains.
+func (t *tree) rename(from, to string) error {
+	move := func(q string) (string, bool) {
+		switch {
+		case q == from:
+			return to, true
+		case strings.HasPrefix(q, from+"/"):
+			return to + strings.TrimPrefix(q, from), true
+		}
+		return q, false
+	}
+	for _, n := range t.nodes {
+		if _, moved := move(n.Path); !moved && (n.Path == to || strings.HasPrefix(n.Path, to+"/") || strings.HasPrefix(to, n.Path+"/")) {
+			return fmt.Errorf("%s is already in the tree", to)
+		}
+	}
+	for i := range t.nodes {
+		t.nodes[i].Path, _ = move(t.nodes[i].Path)
+		deps := slices.Clone(t.nodes[i].DependsOn)
+		for j := range deps {
+			deps[j], _ = move(deps[j])
+		}
+		t.nodes[i].DependsOn = deps
+	}
+	if t.collapsed[from] {
+		delete(t.collapsed, from)
+		t.collapsed[to] = true
+	}
+	return nil
+}
+
+// cleanPath normalizes a path entered by the user, or returns an empty
+// string if it isn't a relative path inside the project.
+func cleanPath(p string) string {
+	p = path.Clean(strings.TrimSpace(p))
+	if p == "." || path.IsAbs(p) || p == ".." || strings.HasPrefix(p, "../") {
+		return ""
+	}
+	return p
+}
+
+func (m *appModel) updateTree(msg tea.Msg) (tea.Model, tea.Cmd) {
+	t := m.tree
+	k, isKey := msg.(tea.KeyMsg)
+	if t.mode != treeBrowsing {
+		if isKey {
+			switch k.Type {
+			case tea.KeyEsc:
+				t.mode, t.status = treeBrowsing, ""
+				t.input.Blur()
+				return m, nil
+			case tea.KeyEnter:
+				return m, m.treeSubmit()
+			}
+		}
+		var cmd tea.Cmd
+		t.input, cmd = t.input.Update(msg)
+		return m, cmd
+	}
+	if !isKey {
+		return m, nil
+	}
+
+	t.status = ""
+	r, ok := t.selected()
+	switch k.String() {
+	case "up", "k":
+		t.cursor = max(0, t.cursor-1)
+	case "down", "j":
+		t.cursor = max(0, min(len(t.rows)-1, t.cursor+1))
+	case "enter", " ", "right", "l":
+		if ok && r.node < 0 {
+			t.collapsed[r.path] = !t.collapsed[r.path]
+			t.build()
+		}
+	case "left", "h":
+		switch {
+		case !ok:
+		case r.node < 0 && !t.collapsed[r.path]:
+			t.collapsed[r.path] = true
+			t.build()
+		case strings.Contains(r.path, "/"):
+			// Move to the parent directory.
+			parent := path.Dir(r.path)
+			t.cursor = max(0, slices.IndexFunc(t.rows, func(row treeRow) bool { return row.path == parent && row.node < 0 }))
+		}
+	case "q", "esc":
+		// Closing without saving discards any edits.
+		m.finish(nil)
+		return m, nil
+	}
+	if !t.editable {
+		return m, nil
+	}
+
+	switch k.String() {
+	case "ctrl+s":
+		m.finish(t.nodes)
+		return m, nil
+	case "a":
+		return m, t.prompt(treeAdding, "New file: ", t.dir())
+	case "r":
+		if ok {
+			return m, t.prompt(treeRenaming, "Rename to: ", r.path)
+		}
+	case "d", "delete":
+		if ok {
+			t.status = fmt.Sprintf("removed %s", plural(t.remove(r.path), "file"))
+			t.build()
+		}
+	case "t":
+		if ok && r.node >= 0 {
+			n := &t.nodes[r.node]
+			if n.Action == "modify" {
+				n.Action = "create"
+			} else {
+				n.Action = "modify"
+			}
+		}
+	}
+	return m, nil
+}
+
+// treeSubmit finishes entering a path or purpose.
+func (m *appModel) treeSubmit() tea.Cmd {
+	t := m.tree
+	value := strings.TrimSpace(t.input.Value())
+	switch t.mode {
+	case treeAdding:
+		p := cleanPath(value)
+		if p == "" || strings.HasSuffix(value, "/") {
+			t.status = "enter the path of a file inside the project"
+			return nil
+		}
+		if slices.ContainsFunc(t.nodes, func(n TreeNode) bool { return n.Path == p || strings.HasPrefix(n.Path, p+"/") }) {
+			t.status = p + " is already in the tree"
+			return nil
+		}
+		t.adding = TreeNode{Path: p, Action: "create", DependsOn: []string{}}
+		return t.prompt(treeDescribing, "Purpose: ", "")
+	case treeDescribing:
+		t.adding.Purpose = value
+		t.nodes = append(t.nodes, t.adding)
+		// Show the new file, even if its directory was collapsed.
+		for d := path.Dir(t.adding.Path); d != "."; d = path.Dir(d) {
+			delete(t.collapsed, d)
+		}
+		t.build()
+		t.cursor = max(0, slices.IndexFunc(t.rows, func(r treeRow) bool { return r.path == t.adding.Path }))
+		t.status = "added " + t.adding.Path
+	case treeRenaming:
+		r, _ := t.selected()
+		p := cleanPath(value)
+		if p == "" {
+			t.status = "enter a path inside the project"
+			return nil
+		}
+		if p != r.path {
+			if err := t.rename(r.path, p); err != nil {
+				t.status = err.Error()
+				return nil
+			}
+			t.build()
+			t.cursor = max(0, slices.IndexFunc(t.rows, func(row treeRow) bool { return row.path == p }))
+			t.status = fmt.Sprintf("renamed %s to %s", r.path, p)
+		}
+	}
+	t.mode = treeBrowsing
+	t.input.Blur()
+	return nil
+}
+
+func (m *appModel) treeView() string {
+	t := m.tree
+	var b strings.Builder
+	b.WriteString(titleBarStyle.Render(m.title) + "\n")
+	if t.problem != "" {
+		b.WriteString("\n" + errorStyle(wrap(t.problem, max(20, m.width-2))) + "\n")
+	}
+	b.WriteString("\n")
+
+	room := max(3, m.height-strings.Count(b.String(), "\n")-treeDetailLines-4)
+	first := max(0, min(t.cursor-room/2, len(t.rows)-room))
+	if len(t.rows) == 0 {
+		b.WriteString(itemStyle.Render(helpStyle("no files")) + "\n")
+	}
+	for i := first; i < min(len(t.rows), first+room); i++ {
+		r := t.rows[i]
+		indent := strings.Repeat("  ", r.depth)
+		var line string
+		if r.node < 0 {
+			icon := "▾"
+			if t.collapsed[r.path] {
+				icon = "▸"
+			}
+			line = fmt.Sprintf("%s%s %s/", indent, icon, path.Base(r.path))
+		} else {
+			n := t.nodes[r.node]
+			line = fmt.Sprintf("%s  %s", indent, path.Base(n.Path))
+			if n.Action == "modify" {
+				line += " (modify)"
+			}
+			if n.Status != "" {
+				line += " " + helpStyle(n.Status)
+			}
+		}
+		if i == t.cursor {
+			b.WriteString(selectedItemStyle.Render("> "+line) + "\n")
+		} else {
+			b.WriteString(itemStyle.Render(line) + "\n")
+		}
+	}
+
+	b.WriteString("\n" + t.detailView(max(20, m.width-6)) + "\n")
+	switch {
+	case t.mode != treeBrowsing:
+		b.WriteString(t.input.View() + "\n")
+		b.WriteString(helpStyle("enter: ok • esc: cancel"))
+	default:
+		if t.status != "" {
+			b.WriteString(helpStyle(t.status) + "\n")
+		}
+		help := "↑/↓: move • enter/←/→: expand or collapse • q: close"
+		if t.editable {
+			help = "↑/↓: move • enter/←/→: expand or collapse • a: add • r: rename • d: remove • t: toggle create/modify • ctrl+s: save • esc: discard"
+		}
+		b.WriteString(helpStyle(wrap(help, max(20, m.width))))
+	}
+	return b.String()
+}
+
+func (t *tree) detailView(width int) string {
+	r, ok := t.selected()
+	if !ok {
+		return ""
+	}
+	var lines []string
+	if r.node < 0 {
+		files := 0
+		for _, n := range t.nodes {
+			if strings.HasPrefix(n.Path, r.path+"/") {
+				files++
+			}
+		}
+		lines = append(lines, r.path+"/", plural(files, "file"))
+	} else {
+		n := t.nodes[r.node]
+		var usedBy []string
+		for _, other := range t.nodes {
+			if slices.Contains(other.DependsOn, n.Path) {
+				usedBy = append(usedBy, other.Path)
+			}
+		}
+		lines = append(lines, n.Path)
+		if n.Purpose != "" {
+			lines = append(lines, wrap(n.Purpose, width))
+		}
+		lines = append(lines, "Action: "+n.Action)
+		if n.Status != "" {
+			lines = append(lines, "Status: "+n.Status)
+		}
+		if len(n.DependsOn) > 0 {
+			lines = append(lines, "Depends on: "+strings.Join(n.DependsOn, ", "))
+		}
+		if len(usedBy) > 0 {
+			lines = append(lines, "Used by: "+strings.Join(usedBy, ", "))
+		}
+	}
+	return quitTextStyle.Render(streamStyle(strings.Join(lines, "\n")))
+}
@@ -0,0 +1,69 @@
+package validate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+// runCheck runs a configured check over the matched files, which are written
+// to a temporary copy of their paths so nothing reaches the project before
+// the user accepts it. {files} in the command is replaced with the matched
+// paths, and DEVOID_PROJECT_PATH points at the project, e.g. for config files.
+func (v *Validator) runCheck(ctx context.Context, projectPath string, c check, files map[string]string, matched []string) ([]Diagnostic, error) {
+	if v.runner == nil {
+		return nil, nil
+	}
+	tmp, err := os.MkdirTemp("", "devoid-check-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	if tmp, err = filepath.EvalSymlinks(tmp); err != nil {
+		return nil, err
+	}
+	for _, p := range matched {
+		dest := filepath.Join(tmp, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(dest, []byte(files[p]), 0o644); err != nil {
+			return nil, err
+		}
+	}
+
+	var quoted []string
+	for _, p := range matched {
+		quoted = append(quoted, shellQuote(p))
+	}
+	abs, err := filepath.Abs(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	command := "export DEVOID_PROJECT_PATH=" + shellQuote(abs) + "; " + strings.ReplaceAll(c.Command, "{files}", strings.Join(quoted, " "))
+	res, err := v.runner.Run(ctx, tmp, command)
+	if err == nil || res == nil {
+		return nil, nil
+	}
+	// The shell exits with 126 or 127 when the tool can't be run at all.
+	if res.ExitCode == 126 || res.ExitCode == 127 || res.ExitCode == -1 {
+		log.Warn("check couldn't run, skipping it", "check", c.Name, "output", strings.TrimSpace(res.Output))
+		return nil, nil
+	}
+	diagnostics := parse(c.Name, res.Output, c.pattern, tmp, files)
+	if len(diagnostics) == 0 {
+		// Without parseable output, attribute the failure to every file the
+		// check ran on so the model at least sees what went wrong.
+		output := strings.TrimSpace(res.Output)
+		if len(output) > 2000 {
+			output = output[:2000] + "..."
+		}
+		for _, p := range matched {
+			diagnostics = append(diagnostics, Diagnostic{Path: p, Tool: c.Name, Message: "failed: " + output})
+		}
+	}
+	return diagnostics, nil
+}
@@ -0,0 +1,194 @@
+package validate
+
+import (
+	"context"
+	goerrors "errors"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+// maxMirrorBytes caps the size of the project copy go vet runs on.
+const maxMirrorBytes = 64 * 1024 * 1024
+
+var (
+	errTooLarge = goerrors.New("project too large")
+
+	skipDirs = map[string]bool{".git": true, ".devoid": true, "node_modules": true, ".venv": true, "target": true}
+)
+
+// environmentErrors are go vet failures caused by the offline environment,
+// e.g. dependencies missing from the module cache, rather than the code.
+var environmentErrors = []string{
+	"no required module provides package",
+	"module lookup disabled",
+	"missing go.sum entry",
+	"cannot find module",
+	"go: updates to go.mod needed",
+	"go.mod file not found",
+}
+
+// validateGo parses and gofmts every Go file, then vets them with the rest of
+// the project if they all parse.
+func (v *Validator) validateGo(ctx context.Context, projectPath string, files map[string]string) ([]Diagnostic, error) {
+	var (
+		diagnostics []Diagnostic
+		goFiles     []string
+	)
+	for p, content := range files {
+		if path.Ext(p) != ".go" {
+			continue
+		}
+		goFiles = append(goFiles, p)
+		fset := token.NewFileSet()
+		_, err := parser.ParseFile(fset, p, content, parser.AllErrors)
+		var list scanner.ErrorList
+		if goerrors.As(err, &list) {
+			for _, e := range list {
+				diagnostics = append(diagnostics, Diagnostic{Path: p, Line: e.Pos.Line, Column: e.Pos.Column, Tool: "go/parser", Message: e.Msg})
+			}
+			continue
+		}
+		if err != nil {
+			diagnostics = append(diagnostics, Diagnostic{Path: p, Tool: "go/parser", Message: err.Error()})
+			continue
+		}
+		if formatted, err := format.Source([]byte(content)); err == nil && string(formatted) != content {
+			log.Info("formatted generated file with gofmt", "path", p)
+			files[p] = string(formatted)
+		}
+	}
+	if len(goFiles) == 0 || len(diagnostics) > 0 {
+		return diagnostics, nil
+	}
+	vet, err := v.vet(ctx, projectPath, files)
+	if err != nil {
+		return nil, err
+	}
+	return append(diagnostics, vet...), nil
+}
+
+// vet runs go vet over a temporary copy of the project with the generated
+// files in place, so nothing is written to the project until the user accepts
+// them.
+func (v *Validator) vet(ctx context.Context, projectPath string, files map[string]string) ([]Diagnostic, error) {
+	if v.runner == nil {
+		return nil, nil
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		log.Warn("go isn't installed, skipping go vet")
+		return nil, nil
+	}
+	if _, ok := files["go.mod"]; !ok {
+		if _, err := os.Stat(filepath.Join(projectPath, "go.mod")); err != nil {
+			log.Warn("the project has no go.mod, skipping go vet")
+			return nil, nil
+		}
+	}
+
+	tmp, err := os.MkdirTemp("", "devoid-vet-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	if tmp, err = filepath.EvalSymlinks(tmp); err != nil {
+		return nil, err
+	}
+	copied, err := mirror(projectPath, tmp)
+	if err != nil {
+		return nil, err
+	}
+	if !copied {
+		log.Warn("the project is too large to copy for go vet, skipping it")
+		return nil, nil
+	}
+	for p, content := range files {
+		dest := filepath.Join(tmp, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(dest, []byte(content), 0o644); err != nil {
+			return nil, err
+		}
+	}
+
+	// Dependencies come from the host's module cache, read-only and without
+	// network access.
+	env := "GOPROXY=off GOFLAGS=-mod=readonly"
+	if out, err := exec.Command(goBin, "env", "GOMODCACHE").Output(); err == nil && strings.TrimSpace(string(out)) != "" {
+		env += " GOMODCACHE=" + shellQuote(strings.TrimSpace(string(out)))
+	}
+	res, err := v.runner.Run(ctx, tmp, env+" go vet ./...")
+	if err == nil || res == nil {
+		return nil, nil
+	}
+	for _, e := range environmentErrors {
+		if strings.Contains(res.Output, e) {
+			log.Warn("go vet couldn't run offline, skipping it", "reason", e)
+			return nil, nil
+		}
+	}
+	diagnostics := parse("go vet", res.Output, defaultPattern, tmp, files)
+	if len(diagnostics) == 0 {
+		log.Warn("go vet failed without reporting problems in generated files", "output", res.Output)
+	}
+	return diagnostics, nil
+}
+
+// mirror copies the project into dir, skipping version control, devoid's own
+// state and dependency directories. It returns false without copying
+// everything if the project is larger than maxMirrorBytes.
+func mirror(projectPath, dir string) (bool, error) {
+	var total int64
+	err := filepath.WalkDir(projectPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(projectPath, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		if d.IsDir() {
+			if skipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(filepath.Join(dir, rel), 0o755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if total += info.Size(); total > maxMirrorBytes {
+			return errTooLarge
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dir, rel), b, 0o644)
+	})
+	if goerrors.Is(err, errTooLarge) {
+		return false, nil
+	}
+	if goerrors.Is(err, fs.ErrNotExist) {
+		return true, nil
+	}
+	return err == nil, err
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
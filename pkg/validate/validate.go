@@ -0,0 +1,226 @@
+package validate
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/zachwalton/devoid/pkg/config"
+	"github.com/zachwalton/devoid/pkg/errors"
+	"github.com/zachwalton/devoid/pkg/runner"
+)
+
+const (
+	// DefaultRetries is how many times the model is asked to fix diagnostics
+	// before the result is shown to the user as it is.
+	DefaultRetries = 3
+	// maxDiagnostics caps the diagnostics reported for a single file.
+	maxDiagnostics = 10
+)
+
+// defaultPattern matches the file:line:column: message format most compilers
+// and linters can produce, optionally prefixed with the tool's name as go vet
+// does for type errors.
+var defaultPattern = regexp.MustCompile(`(?m)^(?:vet: )?(?P<path>[^\s:][^:\n]*):(?P<line>\d+):(?:(?P<column>\d+):)?\s*(?P<message>.+)$`)
+
+type (
+	// Validator runs offline static checks on generated files.
+	Validator struct {
+		checks []check
+		// runner is nil when commands can't run, in which case only the
+		// checks that run in process are used.
+		runner *runner.Runner
+	}
+
+	// Diagnostic is a problem found in a file.
+	Diagnostic struct {
+		Path    string `json:"path"`
+		Line    int    `json:"line,omitempty"`
+		Column  int    `json:"column,omitempty"`
+		Tool    string `json:"tool"`
+		Message string `json:"message"`
+	}
+
+	check struct {
+		config.Check
+		extensions map[string]bool
+		pattern    *regexp.Regexp
+	}
+)
+
+// New returns a validator for the configured checks. Commands run in the
+// sandbox without network access; if the sandbox isn't available, they're
+// skipped.
+func New(cfg config.Validation, sandbox config.Sandbox) (*Validator, error) {
+	v := &Validator{}
+	for i, c := range cfg.Checks {
+		compiled, err := compile(c)
+		if err != nil {
+			return nil, fmt.Errorf("%w: validation -> checks -> %d (%s): %s", errors.ErrInvalidConfig, i, c.Name, err)
+		}
+		v.checks = append(v.checks, compiled)
+	}
+	sandbox.NoNetwork = true
+	r, err := runner.New(sandbox)
+	switch {
+	case goerrors.Is(err, errors.ErrSandboxUnavailable):
+		log.Warn("commands can't run, so only built-in checks will validate generated files", "reason", err)
+	case err != nil:
+		return nil, err
+	default:
+		v.runner = r
+	}
+	return v, nil
+}
+
+// Validate checks files, keyed by their path relative to projectPath.
+// Formatting that can be fixed mechanically, e.g. gofmt, is fixed in files
+// rather than reported.
+func (v *Validator) Validate(ctx context.Context, projectPath string, files map[string]string) ([]Diagnostic, error) {
+	diagnostics, err := v.validateGo(ctx, projectPath, files)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range v.checks {
+		var matched []string
+		for p := range files {
+			if c.extensions[path.Ext(p)] {
+				matched = append(matched, p)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		sort.Strings(matched)
+		found, err := v.runCheck(ctx, projectPath, c, files, matched)
+		if err != nil {
+			return nil, err
+		}
+		diagnostics = append(diagnostics, found...)
+	}
+	return limitPerFile(diagnostics), nil
+}
+
+func (d Diagnostic) String() string {
+	var b strings.Builder
+	b.WriteString(d.Path)
+	if d.Line > 0 {
+		fmt.Fprintf(&b, ":%d", d.Line)
+		if d.Column > 0 {
+			fmt.Fprintf(&b, ":%d", d.Column)
+		}
+	}
+	fmt.Fprintf(&b, ": %s: %s", d.Tool, d.Message)
+	return b.String()
+}
+
+// Error describes diagnostics for the model, grouped by file.
+func Error(diagnostics []Diagnostic) error {
+	var paths []string
+	byPath := map[string][]Diagnostic{}
+	for _, d := range diagnostics {
+		if _, ok := byPath[d.Path]; !ok {
+			paths = append(paths, d.Path)
+		}
+		byPath[d.Path] = append(byPath[d.Path], d)
+	}
+	var b strings.Builder
+	for _, p := range paths {
+		fmt.Fprintf(&b, "\nfiles -> %q:\n", p)
+		for _, d := range byPath[p] {
+			fmt.Fprintf(&b, "  - %s\n", d)
+		}
+	}
+	return fmt.Errorf(
+		"%w: %w: static checks found problems in %d file(s). Fix them and return every file again:%s",
+		errors.ErrRecoverable, errors.ErrValidationFailed, len(paths), b.String(),
+	)
+}
+
+// parse extracts diagnostics for the given files from tool output. Paths in
+// the output are made relative to dir; lines about other files are dropped.
+func parse(tool, output string, pattern *regexp.Regexp, dir string, files map[string]string) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, m := range pattern.FindAllStringSubmatch(output, -1) {
+		d := Diagnostic{Tool: tool}
+		for i, name := range pattern.SubexpNames() {
+			switch name {
+			case "path":
+				d.Path = relative(dir, m[i])
+			case "line":
+				d.Line, _ = strconv.Atoi(m[i])
+			case "column":
+				d.Column, _ = strconv.Atoi(m[i])
+			case "message":
+				d.Message = strings.TrimSpace(m[i])
+			}
+		}
+		if _, ok := files[d.Path]; ok && d.Message != "" {
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	return diagnostics
+}
+
+func relative(dir, p string) string {
+	p = strings.TrimPrefix(p, dir+"/")
+	return path.Clean(strings.TrimPrefix(p, "./"))
+}
+
+func limitPerFile(diagnostics []Diagnostic) []Diagnostic {
+	var kept []Diagnostic
+	seen := map[string]bool{}
+	counts := map[string]int{}
+	for _, d := range diagnostics {
+		if seen[d.String()] {
+			continue
+		}
+		seen[d.String()] = true
+		counts[d.Path]++
+		if counts[d.Path] <= maxDiagnostics {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
+
+func compile(c config.Check) (check, error) {
+	compiled := check{Check: c, extensions: map[string]bool{}, pattern: defaultPattern}
+	if c.Name == "" {
+		return compiled, fmt.Errorf("name is required")
+	}
+	if c.Command == "" {
+		return compiled, fmt.Errorf("command is required")
+	}
+	if len(c.Extensions) == 0 {
+		return compiled, fmt.Errorf("at least one extension is required")
+	}
+	for _, e := range c.Extensions {
+		if !strings.HasPrefix(e, ".") {
+			e = "." + e
+		}
+		compiled.extensions[e] = true
+	}
+	if c.Pattern != "" {
+		re, err := regexp.Compile("(?m)" + c.Pattern)
+		if err != nil {
+			return compiled, err
+		}
+		names := map[string]bool{}
+		for _, n := range re.SubexpNames() {
+			names[n] = true
+		}
+		if !names["path"] || !names["message"] {
+			return compiled, fmt.Errorf("pattern must have named groups path and message")
+		}
+		compiled.pattern = re
+	}
+	return compiled, nil
+}
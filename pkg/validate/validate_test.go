@@ -0,0 +1,110 @@
+package validate
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/zachwalton/devoid/pkg/config"
+)
+
+func TestValidateGo(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       map[string]string
+		want        map[string]string
+		diagnostics []string
+	}{
+		{
+			name:  "formatted",
+			files: map[string]string{"main.go": "package main\n\nfunc main() {}\n"},
+			want:  map[string]string{"main.go": "package main\n\nfunc main() {}\n"},
+		},
+		{
+			name:  "gofmt is applied",
+			files: map[string]string{"main.go": "package main\nfunc main(){\nx:=1\n_ = x}\n"},
+			want:  map[string]string{"main.go": "package main\n\nfunc main() {\n\tx := 1\n\t_ = x\n}\n"},
+		},
+		{
+			name:  "other files are left alone",
+			files: map[string]string{"README.md": "#  Title\n", "main.go": "package main\n"},
+			want:  map[string]string{"README.md": "#  Title\n", "main.go": "package main\n"},
+		},
+		{
+			name:        "syntax error",
+			files:       map[string]string{"main.go": "packag main\n"},
+			want:        map[string]string{"main.go": "packag main\n"},
+			diagnostics: []string{"main.go:1:1: go/parser: expected 'package', found packag"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Without a runner only the in-process checks run.
+			v := &Validator{}
+			diagnostics, err := v.Validate(context.Background(), t.TempDir(), tt.files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, d := range diagnostics {
+				got = append(got, d.String())
+			}
+			if !slices.Equal(got, tt.diagnostics) {
+				t.Errorf("Validate() = %q, want %q", got, tt.diagnostics)
+			}
+			for p, want := range tt.want {
+				if tt.files[p] != want {
+					t.Errorf("files[%q] = %q, want %q", p, tt.files[p], want)
+				}
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	files := map[string]string{"main.go": "", "pkg/util.go": ""}
+	output := `vet: /tmp/x/main.go:3:2: undefined: y
+./pkg/util.go:10: unreachable code
+other.go:1:1: not generated
+# github.com/acme/app
+`
+	want := []string{
+		"main.go:3:2: go vet: undefined: y",
+		"pkg/util.go:10: go vet: unreachable code",
+	}
+	var got []string
+	for _, d := range parse("go vet", output, defaultPattern, "/tmp/x", files) {
+		got = append(got, d.String())
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("parse() = %q, want %q", got, want)
+	}
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name    string
+		check   config.Check
+		wantErr bool
+	}{
+		{name: "valid", check: config.Check{Name: "ruff", Command: "ruff check {files}", Extensions: []string{".py"}}},
+		{name: "extension without a dot", check: config.Check{Name: "ruff", Command: "ruff check {files}", Extensions: []string{"py"}}},
+		{name: "custom pattern", check: config.Check{Name: "x", Command: "x", Extensions: []string{".py"}, Pattern: `^(?P<path>\S+) (?P<message>.+)$`}},
+		{name: "no name", check: config.Check{Command: "x", Extensions: []string{".py"}}, wantErr: true},
+		{name: "no command", check: config.Check{Name: "x", Extensions: []string{".py"}}, wantErr: true},
+		{name: "no extensions", check: config.Check{Name: "x", Command: "x"}, wantErr: true},
+		{name: "invalid pattern", check: config.Check{Name: "x", Command: "x", Extensions: []string{".py"}, Pattern: "("}, wantErr: true},
+		{name: "pattern without named groups", check: config.Check{Name: "x", Command: "x", Extensions: []string{".py"}, Pattern: `^(\S+)`}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := compile(tt.check)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("compile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !c.extensions[".py"] {
+				t.Errorf("compile() extensions = %v, want .py", c.extensions)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package workspace
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	goerrors "errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zachwalton/devoid/pkg/errors"
+)
+
+const (
+	WriteCreate = "create"
+	WriteModify = "modify"
+)
+
+// reserved paths are never writable through the workspace.
+var reserved = []string{".git", ".devoid"}
+
+type (
+	// Workspace is the only way stages write files. Every path is resolved
+	// against the project directory, and writes that would escape it, follow
+	// a symlink or overwrite a file the workspace didn't create are refused.
+	Workspace struct {
+		root    string
+		mu      sync.Mutex
+		created map[string]bool
+		allowed map[string]bool
+		writes  []Write
+	}
+
+	// Write records a single file written through the workspace.
+	Write struct {
+		Path   string    `json:"path"`
+		Action string    `json:"action"`
+		Bytes  int       `json:"bytes"`
+		SHA256 string    `json:"sha256"`
+		Time   time.Time `json:"time"`
+	}
+)
+
+func New(projectPath string) (*Workspace, error) {
+	abs, err := filepath.Abs(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(abs, 0o755); err != nil {
+		return nil, err
+	}
+	root, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return nil, err
+	}
+	return &Workspace{
+		root:    root,
+		created: map[string]bool{},
+		allowed: map[string]bool{},
+	}, nil
+}
+
+func (w *Workspace) Root() string {
+	return w.root
+}
+
+// Allow permits overwriting an existing file the workspace didn't create,
+// e.g. one the user approved modifying.
+func (w *Workspace) Allow(rel string) error {
+	clean, err := w.clean(rel)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.allowed[clean] = true
+	return nil
+}
+
+// Resolve returns the absolute path for rel, rejecting paths that escape the
+// project directory or traverse a symlink.
+func (w *Workspace) Resolve(rel string) (string, error) {
+	clean, err := w.clean(rel)
+	if err != nil {
+		return "", err
+	}
+	p := w.root
+	for _, part := range strings.Split(clean, "/") {
+		p = filepath.Join(p, part)
+		info, err := os.Lstat(p)
+		if goerrors.Is(err, fs.ErrNotExist) {
+			// Nothing below a missing path can be a symlink yet.
+			return filepath.Join(w.root, filepath.FromSlash(clean)), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return "", fmt.Errorf("%w: %q traverses the symlink %q", errors.ErrUnsafePath, rel, p)
+		}
+	}
+	return p, nil
+}
+
+// Read returns the content of an existing file in the workspace.
+func (w *Workspace) Read(rel string) ([]byte, error) {
+	p, err := w.Resolve(rel)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(p)
+}
+
+// Write writes content to rel, creating parent directories as needed.
+func (w *Workspace) Write(rel string, content []byte) (*Write, error) {
+	clean, err := w.clean(rel)
+	if err != nil {
+		return nil, err
+	}
+	p, err := w.Resolve(clean)
+	if err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	action := WriteCreate
+	info, err := os.Lstat(p)
+	switch {
+	case err == nil && info.IsDir():
+		return nil, fmt.Errorf("%w: %q is a directory", errors.ErrUnsafePath, rel)
+	case err == nil:
+		if !w.created[clean] && !w.allowed[clean] {
+			return nil, fmt.Errorf("%w: %q already exists and wasn't created by devoid", errors.ErrOverwrite, rel)
+		}
+		action = WriteModify
+	case !goerrors.Is(err, fs.ErrNotExist):
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return nil, err
+	}
+	// Re-check now that parent directories exist, in case one of them was
+	// swapped for a symlink in the meantime.
+	if _, err := w.Resolve(clean); err != nil {
+		return nil, err
+	}
+	mode := fs.FileMode(0o644)
+	if info != nil {
+		mode = info.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), "."+filepath.Base(p)+".devoid-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		return nil, err
+	}
+
+	if action == WriteCreate {
+		w.created[clean] = true
+	}
+	sum := sha256.Sum256(content)
+	record := Write{
+		Path:   clean,
+		Action: action,
+		Bytes:  len(content),
+		SHA256: hex.EncodeToString(sum[:]),
+		Time:   time.Now(),
+	}
+	w.writes = append(w.writes, record)
+	return &record, nil
+}
+
+// Writes returns every write made through the workspace, in order.
+func (w *Workspace) Writes() []Write {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]Write{}, w.writes...)
+}
+
+// clean normalizes rel to a slash-separated path inside the project.
+func (w *Workspace) clean(rel string) (string, error) {
+	if rel == "" {
+		return "", fmt.Errorf("%w: path is empty", errors.ErrUnsafePath)
+	}
+	slashed := filepath.ToSlash(rel)
+	if path.IsAbs(slashed) || filepath.IsAbs(rel) || filepath.VolumeName(rel) != "" {
+		return "", fmt.Errorf("%w: %q is absolute", errors.ErrUnsafePath, rel)
+	}
+	clean := path.Clean(slashed)
+	if clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("%w: %q is outside the project directory", errors.ErrUnsafePath, rel)
+	}
+	for _, r := range reserved {
+		if clean == r || strings.HasPrefix(clean, r+"/") {
+			return "", fmt.Errorf("%w: %q is reserved", errors.ErrUnsafePath, rel)
+		}
+	}
+	return clean, nil
+}
@@ -0,0 +1,152 @@
+package workspace
+
+import (
+	goerrors "errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zachwalton/devoid/pkg/errors"
+)
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T, root string)
+		path    string
+		allow   bool
+		action  string
+		wantErr error
+	}{
+		{
+			name:   "new file",
+			path:   "cmd/main.go",
+			action: WriteCreate,
+		},
+		{
+			name:    "absolute path",
+			path:    "/etc/passwd",
+			wantErr: errors.ErrUnsafePath,
+		},
+		{
+			name:    "escaping path",
+			path:    "cmd/../../outside.go",
+			wantErr: errors.ErrUnsafePath,
+		},
+		{
+			name:    "project directory",
+			path:    ".",
+			wantErr: errors.ErrUnsafePath,
+		},
+		{
+			name:    "git directory",
+			path:    ".git/hooks/pre-commit",
+			wantErr: errors.ErrUnsafePath,
+		},
+		{
+			name:    "devoid directory",
+			path:    "./.devoid/checkpoints/initial.json",
+			wantErr: errors.ErrUnsafePath,
+		},
+		{
+			name: "symlinked parent directory",
+			setup: func(t *testing.T, root string) {
+				if err := os.Symlink(t.TempDir(), filepath.Join(root, "link")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			path:    "link/main.go",
+			wantErr: errors.ErrUnsafePath,
+		},
+		{
+			name: "existing file",
+			setup: func(t *testing.T, root string) {
+				if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			path:    "main.go",
+			wantErr: errors.ErrOverwrite,
+		},
+		{
+			name: "existing file allowed",
+			setup: func(t *testing.T, root string) {
+				if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			path:   "main.go",
+			allow:  true,
+			action: WriteModify,
+		},
+		{
+			name: "directory",
+			setup: func(t *testing.T, root string) {
+				if err := os.Mkdir(filepath.Join(root, "cmd"), 0o755); err != nil {
+					t.Fatal(err)
+				}
+			},
+			path:    "cmd",
+			allow:   true,
+			wantErr: errors.ErrUnsafePath,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := New(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.setup != nil {
+				tt.setup(t, w.Root())
+			}
+			if tt.allow {
+				if err := w.Allow(tt.path); err != nil {
+					t.Fatal(err)
+				}
+			}
+			record, err := w.Write(tt.path, []byte("package main\n"))
+			if tt.wantErr != nil {
+				if !goerrors.Is(err, tt.wantErr) {
+					t.Errorf("Write(%q) error = %v, want %v", tt.path, err, tt.wantErr)
+				}
+				if len(w.Writes()) != 0 {
+					t.Errorf("Writes() = %v, want none", w.Writes())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if record.Action != tt.action {
+				t.Errorf("Write(%q) action = %q, want %q", tt.path, record.Action, tt.action)
+			}
+			b, err := os.ReadFile(filepath.Join(w.Root(), filepath.FromSlash(tt.path)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != "package main\n" {
+				t.Errorf("content = %q, want %q", b, "package main\n")
+			}
+		})
+	}
+}
+
+func TestWriteTwice(t *testing.T) {
+	w, err := New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, action := range []string{WriteCreate, WriteModify} {
+		record, err := w.Write("main.go", []byte{byte('a' + i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record.Action != action {
+			t.Errorf("write %d action = %q, want %q", i, record.Action, action)
+		}
+	}
+	if got := len(w.Writes()); got != 2 {
+		t.Errorf("len(Writes()) = %d, want 2", got)
+	}
+}